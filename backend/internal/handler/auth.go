@@ -1,15 +1,31 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"vocabweb/internal/middleware"
 	"vocabweb/internal/repository"
+	"vocabweb/internal/service"
 )
 
+// profileStore is the part of UserRepository the auth handler uses
+type profileStore interface {
+	GetUserByID(ctx context.Context, userID string) (*repository.User, error)
+	CreateUser(ctx context.Context, userID, email string) (*repository.User, error)
+	UpdateUser(ctx context.Context, userID string, update repository.ProfileUpdate) (*repository.User, error)
+	UpdateScheduler(ctx context.Context, userID string, scheduler string) (*repository.User, error)
+	UpdateLearningSteps(ctx context.Context, userID string, learningSteps, relearningSteps []int) (*repository.User, error)
+	UpdateDesiredRetention(ctx context.Context, userID string, desiredRetention float64) (*repository.User, error)
+	UpdateDayRolloverHour(ctx context.Context, userID string, hour int) (*repository.User, error)
+	UpdateLeechSettings(ctx context.Context, userID string, threshold int, action string) (*repository.User, error)
+	UpdateStudyLimits(ctx context.Context, userID string, limits repository.StudyLimits) (*repository.User, error)
+}
+
 type AuthHandler struct {
-	userRepo *repository.UserRepository
+	userRepo profileStore
 }
 
 func NewAuthHandler(userRepo *repository.UserRepository) *AuthHandler {
@@ -46,7 +62,8 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(user)
 }
 
-// UpdateProfile updates the current user's profile
+// UpdateProfile handles PUT /api/v1/auth/profile
+// Updates the profile fields and settings sent; omitted ones keep their value
 func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
@@ -55,9 +72,21 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		DisplayName     string `json:"display_name"`
-		Timezone        string `json:"timezone"`
-		DailyReviewGoal int    `json:"daily_review_goal"`
+		DisplayName     *string `json:"display_name"`
+		Timezone        *string `json:"timezone"`
+		DailyReviewGoal *int    `json:"daily_review_goal"`
+		Scheduler       string  `json:"scheduler"`
+		LearningSteps   []int   `json:"learning_steps"`
+		RelearningSteps []int   `json:"relearning_steps"`
+		// Pointer so that an omitted value keeps the current retention
+		DesiredRetention *float64 `json:"desired_retention"`
+		DayRolloverHour  *int     `json:"day_rollover_hour"`
+		LeechThreshold   *int     `json:"leech_threshold"`
+		LeechAction      *string  `json:"leech_action"`
+		NewCardsPerDay   *int     `json:"new_cards_per_day"`
+		ReviewsPerDay    *int     `json:"reviews_per_day"`
+		NewCardRatio     *float64 `json:"new_card_ratio"`
+		NewCardOrder     *string  `json:"new_card_order"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -65,12 +94,175 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.userRepo.UpdateUser(r.Context(), userID, req.DisplayName, req.Timezone, req.DailyReviewGoal)
+	// Validate scheduler selection before touching the profile
+	if req.Scheduler != "" {
+		if _, err := service.NewScheduler(req.Scheduler); err != nil {
+			http.Error(w, "Invalid scheduler", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := service.ValidateSteps(req.LearningSteps); err != nil {
+		http.Error(w, "Invalid learning_steps: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := service.ValidateSteps(req.RelearningSteps); err != nil {
+		http.Error(w, "Invalid relearning_steps: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.DailyReviewGoal != nil && *req.DailyReviewGoal <= 0 {
+		http.Error(w, "Invalid daily_review_goal: must be positive", http.StatusBadRequest)
+		return
+	}
+	if req.DayRolloverHour != nil && (*req.DayRolloverHour < 0 || *req.DayRolloverHour > 23) {
+		http.Error(w, "Invalid day_rollover_hour: must be between 0 and 23", http.StatusBadRequest)
+		return
+	}
+	if req.DesiredRetention != nil {
+		if err := service.ValidateRetention(*req.DesiredRetention); err != nil {
+			http.Error(w, "Invalid desired_retention: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.LeechThreshold != nil || req.LeechAction != nil {
+		threshold, action := service.DefaultLeechThreshold, service.LeechActionTag
+		if req.LeechThreshold != nil {
+			threshold = *req.LeechThreshold
+		}
+		if req.LeechAction != nil {
+			action = *req.LeechAction
+		}
+		if err := service.ValidateLeechSettings(threshold, action); err != nil {
+			http.Error(w, "Invalid leech settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	studyLimitsSet := req.NewCardsPerDay != nil || req.ReviewsPerDay != nil || req.NewCardRatio != nil || req.NewCardOrder != nil
+	if studyLimitsSet {
+		// Omitted settings are checked against the column defaults here and
+		// keep their current values when the profile is updated
+		limits := applyStudyLimits(repository.DefaultStudyLimits, req.NewCardsPerDay, req.ReviewsPerDay, req.NewCardRatio, req.NewCardOrder)
+		if err := service.ValidateStudyLimits(limits); err != nil {
+			http.Error(w, "Invalid study limits: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Settings below are compared with and default to the current profile
+	update := repository.ProfileUpdate{
+		DisplayName:     req.DisplayName,
+		Timezone:        req.Timezone,
+		DailyReviewGoal: req.DailyReviewGoal,
+	}
+	var user *repository.User
+	var err error
+	if update.IsEmpty() {
+		user, err = h.userRepo.GetUserByID(r.Context(), userID)
+	} else {
+		user, err = h.userRepo.UpdateUser(r.Context(), userID, update)
+	}
 	if err != nil {
 		http.Error(w, "Failed to update user profile", http.StatusInternalServerError)
 		return
 	}
+	if user == nil {
+		http.Error(w, "Profile not found", http.StatusNotFound)
+		return
+	}
+
+	if req.Scheduler != "" && req.Scheduler != user.Scheduler {
+		user, err = h.userRepo.UpdateScheduler(r.Context(), userID, req.Scheduler)
+		if err != nil {
+			http.Error(w, "Failed to update scheduler", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Omitted step lists keep their current value; an empty list disables steps
+	if req.LearningSteps != nil || req.RelearningSteps != nil {
+		learningSteps, relearningSteps := user.LearningSteps, user.RelearningSteps
+		if req.LearningSteps != nil {
+			learningSteps = req.LearningSteps
+		}
+		if req.RelearningSteps != nil {
+			relearningSteps = req.RelearningSteps
+		}
+		user, err = h.userRepo.UpdateLearningSteps(r.Context(), userID, learningSteps, relearningSteps)
+		if err != nil {
+			http.Error(w, "Failed to update learning steps", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.DesiredRetention != nil && *req.DesiredRetention != user.DesiredRetention {
+		user, err = h.userRepo.UpdateDesiredRetention(r.Context(), userID, *req.DesiredRetention)
+		if err != nil {
+			http.Error(w, "Failed to update desired retention", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.DayRolloverHour != nil && *req.DayRolloverHour != user.DayRolloverHour {
+		user, err = h.userRepo.UpdateDayRolloverHour(r.Context(), userID, *req.DayRolloverHour)
+		if err != nil {
+			http.Error(w, "Failed to update day rollover hour", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.LeechThreshold != nil || req.LeechAction != nil {
+		// An omitted setting keeps its current value
+		threshold, action := user.LeechThreshold, user.LeechAction
+		if req.LeechThreshold != nil {
+			threshold = *req.LeechThreshold
+		}
+		if req.LeechAction != nil {
+			action = *req.LeechAction
+		}
+		user, err = h.userRepo.UpdateLeechSettings(r.Context(), userID, threshold, action)
+		if err != nil {
+			http.Error(w, "Failed to update leech settings", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if studyLimitsSet {
+		limits := applyStudyLimits(repository.StudyLimits{
+			NewCardsPerDay: user.NewCardsPerDay,
+			ReviewsPerDay:  user.ReviewsPerDay,
+			NewCardRatio:   user.NewCardRatio,
+			NewCardOrder:   user.NewCardOrder,
+		}, req.NewCardsPerDay, req.ReviewsPerDay, req.NewCardRatio, req.NewCardOrder)
+		user, err = h.userRepo.UpdateStudyLimits(r.Context(), userID, limits)
+		if err != nil {
+			http.Error(w, "Failed to update study limits", http.StatusInternalServerError)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// applyStudyLimits overrides limits with the settings given in a request
+func applyStudyLimits(limits repository.StudyLimits, newCardsPerDay, reviewsPerDay *int, newCardRatio *float64, newCardOrder *string) repository.StudyLimits {
+	if newCardsPerDay != nil {
+		limits.NewCardsPerDay = *newCardsPerDay
+	}
+	if reviewsPerDay != nil {
+		limits.ReviewsPerDay = *reviewsPerDay
+	}
+	if newCardRatio != nil {
+		limits.NewCardRatio = *newCardRatio
+	}
+	if newCardOrder != nil {
+		limits.NewCardOrder = *newCardOrder
+	}
+	return limits
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"vocabweb/internal/middleware"
+	"vocabweb/internal/repository"
+)
+
+// fakeProfileStore keeps one profile in memory and records the updates made
+type fakeProfileStore struct {
+	user    repository.User
+	updates []repository.ProfileUpdate
+}
+
+func (f *fakeProfileStore) profile() (*repository.User, error) {
+	user := f.user
+	return &user, nil
+}
+
+func (f *fakeProfileStore) GetUserByID(ctx context.Context, userID string) (*repository.User, error) {
+	return f.profile()
+}
+
+func (f *fakeProfileStore) CreateUser(ctx context.Context, userID, email string) (*repository.User, error) {
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateUser(ctx context.Context, userID string, update repository.ProfileUpdate) (*repository.User, error) {
+	f.updates = append(f.updates, update)
+	if update.DisplayName != nil {
+		f.user.DisplayName = *update.DisplayName
+	}
+	if update.Timezone != nil {
+		f.user.Timezone = *update.Timezone
+	}
+	if update.DailyReviewGoal != nil {
+		f.user.DailyReviewGoal = *update.DailyReviewGoal
+	}
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateScheduler(ctx context.Context, userID string, scheduler string) (*repository.User, error) {
+	f.user.Scheduler = scheduler
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateLearningSteps(ctx context.Context, userID string, learningSteps, relearningSteps []int) (*repository.User, error) {
+	f.user.LearningSteps, f.user.RelearningSteps = learningSteps, relearningSteps
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateDesiredRetention(ctx context.Context, userID string, desiredRetention float64) (*repository.User, error) {
+	f.user.DesiredRetention = desiredRetention
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateDayRolloverHour(ctx context.Context, userID string, hour int) (*repository.User, error) {
+	f.user.DayRolloverHour = hour
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateLeechSettings(ctx context.Context, userID string, threshold int, action string) (*repository.User, error) {
+	f.user.LeechThreshold, f.user.LeechAction = threshold, action
+	return f.profile()
+}
+
+func (f *fakeProfileStore) UpdateStudyLimits(ctx context.Context, userID string, limits repository.StudyLimits) (*repository.User, error) {
+	f.user.NewCardsPerDay, f.user.ReviewsPerDay = limits.NewCardsPerDay, limits.ReviewsPerDay
+	f.user.NewCardRatio, f.user.NewCardOrder = limits.NewCardRatio, limits.NewCardOrder
+	return f.profile()
+}
+
+func updateProfile(t *testing.T, store *fakeProfileStore, body string) (*httptest.ResponseRecorder, repository.User) {
+	t.Helper()
+	h := &AuthHandler{userRepo: store}
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/auth/profile", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, "firebase-uid"))
+	rec := httptest.NewRecorder()
+	h.UpdateProfile(rec, req)
+
+	var user repository.User
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return rec, user
+}
+
+func TestUpdateProfileSchedulerOnly(t *testing.T) {
+	store := &fakeProfileStore{user: repository.User{
+		DisplayName:     "Ada",
+		Timezone:        "Asia/Tokyo",
+		DailyReviewGoal: 30,
+		Scheduler:       "sm2",
+	}}
+
+	rec, user := updateProfile(t, store, `{"scheduler":"fsrs"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if len(store.updates) != 0 {
+		t.Errorf("UpdateUser called with %+v, want no profile update", store.updates)
+	}
+	if store.user.Scheduler != "fsrs" || user.Scheduler != "fsrs" {
+		t.Errorf("scheduler saved %q, returned %q, want fsrs", store.user.Scheduler, user.Scheduler)
+	}
+	if user.DisplayName != "Ada" || user.Timezone != "Asia/Tokyo" || user.DailyReviewGoal != 30 {
+		t.Errorf("profile fields changed: %+v", user)
+	}
+}
+
+func TestUpdateProfileSendsOnlyGivenFields(t *testing.T) {
+	store := &fakeProfileStore{user: repository.User{Timezone: "UTC", DailyReviewGoal: 20, Scheduler: "sm2"}}
+
+	rec, user := updateProfile(t, store, `{"display_name":"Ada"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	if len(store.updates) != 1 {
+		t.Fatalf("UpdateUser called %d times, want 1", len(store.updates))
+	}
+	if update := store.updates[0]; update.DisplayName == nil || *update.DisplayName != "Ada" || update.Timezone != nil || update.DailyReviewGoal != nil {
+		t.Errorf("update = %+v, want only the display name", update)
+	}
+	if user.DisplayName != "Ada" || user.Timezone != "UTC" || user.DailyReviewGoal != 20 {
+		t.Errorf("profile = %+v", user)
+	}
+}
+
+func TestUpdateProfileRejectsInvalidFields(t *testing.T) {
+	for _, body := range []string{
+		`{"daily_review_goal":0}`,
+		`{"timezone":""}`,
+		`{"timezone":"Mars/Olympus"}`,
+		`{"scheduler":"unknown"}`,
+	} {
+		store := &fakeProfileStore{user: repository.User{Scheduler: "sm2"}}
+		rec, _ := updateProfile(t, store, body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+		if len(store.updates) != 0 || store.user.Scheduler != "sm2" {
+			t.Errorf("%s: profile changed", body)
+		}
+	}
+}
@@ -1,93 +1,61 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
-	"strconv"
+	"strings"
 
 	"vocabweb/internal/middleware"
-	"vocabweb/internal/repository"
+	"vocabweb/internal/service"
 )
 
 type DashboardHandler struct {
-	statsRepo *repository.StatsRepository
+	dashboardService *service.DashboardService
 }
 
-func NewDashboardHandler(statsRepo *repository.StatsRepository) *DashboardHandler {
-	return &DashboardHandler{statsRepo: statsRepo}
+func NewDashboardHandler(dashboardService *service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{dashboardService: dashboardService}
 }
 
-// DashboardResponse represents the complete dashboard data
-type DashboardResponse struct {
-	TodayDue      int                        `json:"today_due"`
-	TodayNew      int                        `json:"today_new"`
-	TotalMastered int                        `json:"total_mastered"`
-	StreakDays    int                        `json:"streak_days"`
-	RecentWords   []repository.RecentWord    `json:"recent_words"`
-	WeeklyStats   []repository.DailyStat     `json:"weekly_stats"`
-}
-
-// GetDashboard returns all dashboard statistics for the current user
+// GetDashboard returns all dashboard statistics for the current user. The
+// response carries an ETag, and requests whose If-None-Match matches it get
+// 304 Not Modified, so clients can poll cheaply.
 func (h *DashboardHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
-	userIDStr, ok := r.Context().Value(middleware.UserIDKey).(string)
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
 	if !ok {
 		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
 		return
 	}
 
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	dashboard, err := h.dashboardService.GetDashboard(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, "Failed to get dashboard", http.StatusInternalServerError)
 		return
 	}
 
-	// Fetch all statistics
-	todayDue, err := h.statsRepo.GetTodayDueCount(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "Failed to get today due count", http.StatusInternalServerError)
-		return
-	}
-
-	todayNew, err := h.statsRepo.GetTodayNewCount(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "Failed to get today new count", http.StatusInternalServerError)
+	// The dashboard is per user and changes with every review, so caches must
+	// revalidate it each time
+	w.Header().Set("ETag", dashboard.ETag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), dashboard.ETag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	totalMastered, err := h.statsRepo.GetMasteredCount(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "Failed to get mastered count", http.StatusInternalServerError)
-		return
-	}
-
-	streakDays, err := h.statsRepo.GetStreakDays(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "Failed to get streak days", http.StatusInternalServerError)
-		return
-	}
-
-	recentWords, err := h.statsRepo.GetRecentWords(r.Context(), userID, 5)
-	if err != nil {
-		http.Error(w, "Failed to get recent words", http.StatusInternalServerError)
-		return
-	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(dashboard.JSON)
+}
 
-	weeklyStats, err := h.statsRepo.GetWeeklyStats(r.Context(), userID)
-	if err != nil {
-		http.Error(w, "Failed to get weekly stats", http.StatusInternalServerError)
-		return
+// etagMatches reports whether an If-None-Match header lists etag, comparing
+// weakly as RFC 9110 requires
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
 	}
-
-	// Build response
-	response := DashboardResponse{
-		TodayDue:      todayDue,
-		TodayNew:      todayNew,
-		TotalMastered: totalMastered,
-		StreakDays:    streakDays,
-		RecentWords:   recentWords,
-		WeeklyStats:   weeklyStats,
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	return false
 }
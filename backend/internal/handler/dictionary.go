@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"vocabweb/internal/middleware"
+	"vocabweb/internal/service"
+)
+
+// DictionaryHandler handles global dictionary HTTP requests
+type DictionaryHandler struct {
+	dictionaryService *service.DictionaryService
+}
+
+// NewDictionaryHandler creates a new dictionary handler instance
+func NewDictionaryHandler(dictionaryService *service.DictionaryService) *DictionaryHandler {
+	return &DictionaryHandler{
+		dictionaryService: dictionaryService,
+	}
+}
+
+// Search handles GET /api/v1/dictionary/search
+// Returns the dictionary words best matching q, tolerating typos and
+// matching definitions, or only completing prefixes with mode=autocomplete
+func (h *DictionaryHandler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req := service.DictionarySearchRequest{
+		Query: r.URL.Query().Get("q"),
+		Mode:  r.URL.Query().Get("mode"),
+	}
+
+	// Parse limit from query params
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		req.Limit = parsedLimit
+	}
+
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.dictionaryService.Search(ctx, userID, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    entries,
+	})
+}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"vocabweb/internal/middleware"
+	"vocabweb/internal/service"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// GroupHandler handles word group HTTP requests
+type GroupHandler struct {
+	groupService *service.GroupService
+}
+
+// NewGroupHandler creates a new group handler instance
+func NewGroupHandler(groupService *service.GroupService) *GroupHandler {
+	return &GroupHandler{
+		groupService: groupService,
+	}
+}
+
+// ListGroups handles GET /api/v1/groups
+// Returns the user's groups in their sort order with word and due counts
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groups, err := h.groupService.ListGroups(ctx, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    groups,
+	})
+}
+
+// GetGroup handles GET /api/v1/groups/{id}
+// Returns one group with its word and due counts
+func (h *GroupHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groupService.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    group,
+	})
+}
+
+// CreateGroup handles POST /api/v1/groups
+// Creates a group after the user's existing ones
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groupService.CreateGroup(ctx, userID, req)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    group,
+	})
+}
+
+// UpdateGroup handles PATCH /api/v1/groups/{id}
+// Renames or recolours a group
+func (h *GroupHandler) UpdateGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	var req service.UpdateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.groupService.UpdateGroup(ctx, userID, groupID, req)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    group,
+	})
+}
+
+// DeleteGroup handles DELETE /api/v1/groups/{id}
+// Deletes a group, leaving its words ungrouped
+func (h *GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.groupService.DeleteGroup(ctx, userID, groupID); err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// ReorderGroups handles PUT /api/v1/groups/order
+// Sets the sort order of the user's groups to the order of the given IDs
+func (h *GroupHandler) ReorderGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.ReorderGroupsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.groupService.ReorderGroups(ctx, userID, req)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    groups,
+	})
+}
+
+// MoveWords handles POST /api/v1/groups/move
+// Moves every word matching a filter into a group, or out of their groups
+// when group_id is null
+func (h *GroupHandler) MoveWords(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.MoveWordsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.groupService.MoveWords(ctx, userID, req)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// AddWord handles PUT /api/v1/groups/{id}/words/{word_id}
+// Moves one word into the group from wherever it was
+func (h *GroupHandler) AddWord(w http.ResponseWriter, r *http.Request) {
+	h.moveWord(w, r, func(r *http.Request, userID string, groupID, userWordID uuid.UUID) (*service.MoveWordsResult, error) {
+		return h.groupService.MoveWord(r.Context(), userID, userWordID, &groupID)
+	})
+}
+
+// RemoveWord handles DELETE /api/v1/groups/{id}/words/{word_id}
+// Takes one word out of the group, leaving it ungrouped
+func (h *GroupHandler) RemoveWord(w http.ResponseWriter, r *http.Request) {
+	h.moveWord(w, r, func(r *http.Request, userID string, groupID, userWordID uuid.UUID) (*service.MoveWordsResult, error) {
+		return h.groupService.RemoveWord(r.Context(), userID, groupID, userWordID)
+	})
+}
+
+// moveWord parses a single word move and writes its result
+func (h *GroupHandler) moveWord(w http.ResponseWriter, r *http.Request, move func(*http.Request, string, uuid.UUID, uuid.UUID) (*service.MoveWordsResult, error)) {
+	// Get user ID from context
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	groupID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid group ID", http.StatusBadRequest)
+		return
+	}
+	userWordID, err := uuid.Parse(chi.URLParam(r, "word_id"))
+	if err != nil {
+		http.Error(w, "Invalid user word ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := move(r, userID, groupID, userWordID)
+	if err != nil {
+		writeGroupError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// writeGroupError maps group errors to HTTP status codes
+func writeGroupError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrGroupNotFound), errors.Is(err, service.ErrReviewWordMissing):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrGroupNameTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrEmptyFilter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
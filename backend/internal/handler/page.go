@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"vocabweb/internal/repository"
+)
+
+// parsePageRequest reads the cursor, limit and total query params of a
+// keyset-paginated list. The total is counted on the first page unless
+// total=false, and on later pages only with total=true. Offset pagination
+// params are rejected rather than ignored, so that old clients do not get
+// the first page back for every page.
+func parsePageRequest(r *http.Request) (repository.PageRequest, error) {
+	query := r.URL.Query()
+	for _, param := range []string{"page", "offset"} {
+		if query.Has(param) {
+			return repository.PageRequest{}, fmt.Errorf("%s is not supported: follow next_cursor with the cursor param", param)
+		}
+	}
+	page := repository.PageRequest{
+		Cursor:    query.Get("cursor"),
+		WithTotal: query.Get("cursor") == "",
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return page, fmt.Errorf("limit must be a positive integer")
+		}
+		page.Limit = limit
+	}
+
+	if totalStr := query.Get("total"); totalStr != "" {
+		total, err := strconv.ParseBool(totalStr)
+		if err != nil {
+			return page, fmt.Errorf("total must be true or false")
+		}
+		page.WithTotal = total
+	}
+
+	return page, nil
+}
@@ -2,11 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
+	"vocabweb/internal/middleware"
+	"vocabweb/internal/repository"
 	"vocabweb/internal/service"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
@@ -28,7 +32,7 @@ func (h *ReviewHandler) GetDueReviews(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	
 	// Get user ID from context (set by auth middleware)
-	userID, ok := ctx.Value("user_id").(string)
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
 	if !ok || userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -67,7 +71,7 @@ func (h *ReviewHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	
 	// Get user ID from context
-	userID, ok := ctx.Value("user_id").(string)
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
 	if !ok || userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -75,8 +79,12 @@ func (h *ReviewHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var req struct {
-		UserWordID string `json:"user_word_id"`
-		Quality    int    `json:"quality"`
+		CardID         string `json:"card_id"`
+		UserWordID     string `json:"user_word_id"`
+		Quality        int    `json:"quality"`
+		ResponseTimeMs *int   `json:"response_time_ms"`
+		CardMode       string `json:"card_mode"`
+		ClientType     string `json:"client_type"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -84,20 +92,43 @@ func (h *ReviewHandler) SubmitReview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse UUID
-	userWordID, err := uuid.Parse(req.UserWordID)
-	if err != nil {
-		http.Error(w, "Invalid user_word_id", http.StatusBadRequest)
-		return
+	// Parse UUIDs; either identifies the card
+	var cardID, userWordID uuid.UUID
+	var err error
+	if req.CardID != "" {
+		cardID, err = uuid.Parse(req.CardID)
+		if err != nil {
+			http.Error(w, "Invalid card_id", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.UserWordID != "" {
+		userWordID, err = uuid.Parse(req.UserWordID)
+		if err != nil {
+			http.Error(w, "Invalid user_word_id", http.StatusBadRequest)
+			return
+		}
 	}
 
 	// Submit review
 	submitReq := service.SubmitReviewRequest{
-		UserWordID: userWordID,
-		Quality:    req.Quality,
+		CardID:         cardID,
+		UserWordID:     userWordID,
+		Quality:        req.Quality,
+		ResponseTimeMs: req.ResponseTimeMs,
+		CardMode:       req.CardMode,
+		ClientType:     req.ClientType,
+	}
+	if err := submitReq.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	if err := h.reviewService.SubmitReview(ctx, userID, submitReq); err != nil {
+		if errors.Is(err, service.ErrTypedCard) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -116,7 +147,7 @@ func (h *ReviewHandler) GetReviewStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	
 	// Get user ID from context
-	userID, ok := ctx.Value("user_id").(string)
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
 	if !ok || userID == "" {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -136,3 +167,567 @@ func (h *ReviewHandler) GetReviewStats(w http.ResponseWriter, r *http.Request) {
 		"data":    stats,
 	})
 }
+
+// GetReviewHistory handles GET /api/v1/review/history
+// Returns a page of the user's reviews, newest first, optionally of one word
+// (user_word_id) or session (session_id)
+func (h *ReviewHandler) GetReviewHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	query := repository.ReviewHistoryQuery{PageRequest: page}
+
+	if idStr := r.URL.Query().Get("user_word_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			http.Error(w, "Invalid user word ID", http.StatusBadRequest)
+			return
+		}
+		query.UserWordID = &id
+	}
+	if idStr := r.URL.Query().Get("session_id"); idStr != "" {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			return
+		}
+		query.SessionID = &id
+	}
+
+	if err := query.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.reviewService.ListReviewHistory(ctx, userID, query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// GetParameters handles GET /api/v1/review/parameters
+// Returns the user's scheduler parameters and their expected retention
+func (h *ReviewHandler) GetParameters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	fit, err := h.reviewService.GetParameters(ctx, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    fit,
+	})
+}
+
+// OptimizeParameters handles POST /api/v1/review/parameters/optimize
+// Fits the user's scheduler parameters to their review history
+func (h *ReviewHandler) OptimizeParameters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	fit, err := h.reviewService.OptimizeParameters(ctx, userID)
+	if errors.Is(err, service.ErrNotEnoughHistory) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    fit,
+	})
+}
+
+// GetRetentionProjection handles GET /api/v1/review/retention/projection
+// Returns the projected daily review workload for a range of desired retention values
+func (h *ReviewHandler) GetRetentionProjection(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse horizon from query params
+	days := service.DefaultProjectionDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays <= 0 {
+			http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		days = parsedDays
+	}
+
+	projection, err := h.reviewService.ProjectWorkload(ctx, userID, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    projection,
+	})
+}
+
+// GetForecast handles GET /api/v1/review/forecast
+// Returns the cards coming due on each of the next days and the projected review load
+func (h *ReviewHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse horizon from query params
+	days := service.DefaultForecastDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays <= 0 {
+			http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		days = parsedDays
+	}
+
+	forecast, err := h.reviewService.ForecastReviews(ctx, userID, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    forecast,
+	})
+}
+
+// CreateSession handles POST /api/v1/review/sessions
+// Starts a review session of due and new cards within today's limits
+func (h *ReviewHandler) CreateSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional; an empty one uses the default session size
+	var req service.CreateSessionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.reviewService.CreateSession(ctx, userID, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    summary,
+	})
+}
+
+// GetNextCard handles GET /api/v1/review/sessions/{id}/next
+// Returns the next card of the session, or completed once no cards are left
+func (h *ReviewHandler) GetNextCard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.reviewService.NextCard(ctx, userID, sessionID)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"completed": card == nil,
+			"card":      card,
+		},
+	})
+}
+
+// SubmitSessionAnswer handles POST /api/v1/review/sessions/{id}/answers
+// Records the answer to the session's current card
+func (h *ReviewHandler) SubmitSessionAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req service.SubmitReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	answer, err := h.reviewService.SubmitSessionAnswer(ctx, userID, sessionID, req)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    answer,
+	})
+}
+
+// GetSessionSummary handles GET /api/v1/review/sessions/{id}/summary
+// Returns accuracy, time spent and XP earned in the session
+func (h *ReviewHandler) GetSessionSummary(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.reviewService.GetSessionSummary(ctx, userID, sessionID)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    summary,
+	})
+}
+
+// writeSessionError maps review session and undo errors to HTTP status codes
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrSessionNotFound), errors.Is(err, service.ErrReviewWordMissing),
+		errors.Is(err, service.ErrNothingToUndo):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrSessionCompleted), errors.Is(err, service.ErrNotCurrentCard),
+		errors.Is(err, service.ErrUndoOutOfOrder), errors.Is(err, service.ErrCardNotDue),
+		errors.Is(err, service.ErrCardReset), errors.Is(err, service.ErrUndoLimit):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrUnknownWordAction), errors.Is(err, service.ErrEmptyFilter):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrNotTypedCard), errors.Is(err, service.ErrTypedCard):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// GradeTypedAnswer handles POST /api/v1/review/grade
+// Grades a typed answer to a recall, spelling or cloze card and records the review
+func (h *ReviewHandler) GradeTypedAnswer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.TypedAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.reviewService.SubmitTypedAnswer(ctx, userID, req)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetLeeches handles GET /api/v1/review/leeches
+// Returns the words the user keeps forgetting, most lapses first
+func (h *ReviewHandler) GetLeeches(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	leeches, err := h.reviewService.GetLeeches(ctx, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"leeches": leeches,
+			"total":   len(leeches),
+		},
+	})
+}
+
+// UpdateWordNotes handles PATCH /api/v1/review/leeches/{id}
+// Rewrites a word's definition or mnemonic
+func (h *ReviewHandler) UpdateWordNotes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userWordID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user word ID", http.StatusBadRequest)
+		return
+	}
+
+	var req service.UpdateWordNotesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	word, err := h.reviewService.UpdateWordNotes(ctx, userID, userWordID, req)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    word,
+	})
+}
+
+// ApplyWordAction handles POST /api/v1/review/words/{id}/{action}
+// Suspends, unsuspends, buries, unburies or resets one word
+func (h *ReviewHandler) ApplyWordAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userWordID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user word ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.reviewService.ApplyWordAction(ctx, userID, userWordID, chi.URLParam(r, "action"))
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// BulkWordAction handles POST /api/v1/review/words/bulk
+// Applies a word action to every word matching a group, tag or status filter
+func (h *ReviewHandler) BulkWordAction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.BulkWordActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.reviewService.ApplyBulkWordAction(ctx, userID, req)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// UndoReview handles POST /api/v1/review/undo
+// Reverts the latest review, optionally limited to one session
+func (h *ReviewHandler) UndoReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// The body is optional; without a session the user's latest review is undone
+	var req service.UndoRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.reviewService.UndoLastReview(ctx, userID, req)
+	if err != nil {
+		writeSessionError(w, err)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"vocabweb/internal/middleware"
+	"vocabweb/internal/service"
+)
+
+// StatsHandler handles learning statistics HTTP requests
+type StatsHandler struct {
+	statsService *service.StatsService
+}
+
+// NewStatsHandler creates a new stats handler instance
+func NewStatsHandler(statsService *service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetRetention handles GET /api/v1/stats/retention
+// Returns true retention, retention by interval, ease distribution, lapse rate and calibration
+func (h *StatsHandler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse window from query params
+	days := service.DefaultRetentionDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsedDays, err := strconv.Atoi(daysStr)
+		if err != nil || parsedDays <= 0 {
+			http.Error(w, "days must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		days = parsedDays
+	}
+
+	stats, err := h.statsService.GetRetentionStats(ctx, userID, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+// GetCalendar handles GET /api/v1/stats/calendar
+// Returns per-day reviews, new words, time spent and goal completion for a year
+func (h *StatsHandler) GetCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user ID from context
+	userID, ok := ctx.Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// Parse year from query params; the current year by default
+	var year int
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		parsedYear, err := strconv.Atoi(yearStr)
+		if err != nil || parsedYear < service.MinCalendarYear || parsedYear > service.MaxCalendarYear {
+			http.Error(w, "year must be between 2000 and 2100", http.StatusBadRequest)
+			return
+		}
+		year = parsedYear
+	}
+
+	calendar, err := h.statsService.GetActivityCalendar(ctx, userID, year)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    calendar,
+	})
+}
@@ -6,10 +6,12 @@ import (
 	"strconv"
 	"strings"
 
+	"vocabweb/internal/middleware"
 	"vocabweb/internal/model"
 	"vocabweb/internal/repository"
 	"vocabweb/internal/service"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
@@ -17,6 +19,7 @@ type WordsHandler struct {
 	wordRepo     *repository.WordRepository
 	userWordRepo *repository.UserWordRepository
 	analyzer     *service.AnalyzerService
+	dashboard    *service.DashboardService
 }
 
 func NewWordsHandler(wordRepo *repository.WordRepository, userWordRepo *repository.UserWordRepository, analyzer *service.AnalyzerService) *WordsHandler {
@@ -27,6 +30,19 @@ func NewWordsHandler(wordRepo *repository.WordRepository, userWordRepo *reposito
 	}
 }
 
+// SetDashboardService sets the dashboard cache to invalidate when words are
+// added or removed
+func (h *WordsHandler) SetDashboardService(dashboard *service.DashboardService) {
+	h.dashboard = dashboard
+}
+
+// invalidateDashboard drops the requesting user's cached dashboard
+func (h *WordsHandler) invalidateDashboard(r *http.Request) {
+	if userID, ok := r.Context().Value(middleware.UserIDKey).(string); ok {
+		h.dashboard.Invalidate(userID)
+	}
+}
+
 // Helper functions
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -106,6 +122,7 @@ func (h *WordsHandler) Create(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to add word to collection")
 		return
 	}
+	h.invalidateDashboard(r)
 
 	respondJSON(w, http.StatusCreated, userWord)
 }
@@ -191,6 +208,7 @@ func (h *WordsHandler) BatchCreate(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to batch add words")
 		return
 	}
+	h.invalidateDashboard(r)
 
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
 		"message": "words added successfully",
@@ -198,66 +216,70 @@ func (h *WordsHandler) BatchCreate(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// List returns user's word collection with pagination and filters
-// GET /api/v1/words?page=1&limit=20&sort=created_at&order=desc&status=learning
+// List returns a page of the user's word collection matching a search
+// GET /api/v1/words?q=ease<2+due:today+is:leech&sort=created_at&order=desc&limit=20&cursor=...&total=true
+// status, group_id (a group ID or none) and tags (a boolean tag query) narrow
+// the search further. Pages continue from the previous page's next_cursor;
+// the total is counted on the first page unless total=false.
 func (h *WordsHandler) List(w http.ResponseWriter, r *http.Request) {
-	userID := getUserID(r)
-	if userID == 0 {
+	userID, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok || userID == "" {
 		respondError(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
 	// Parse query parameters
 	query := r.URL.Query()
-	
-	page, _ := strconv.Atoi(query.Get("page"))
-	if page < 1 {
-		page = 1
-	}
-	
-	limit, _ := strconv.Atoi(query.Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
-	
-	offset := (page - 1) * limit
-	
-	sort := query.Get("sort")
-	if sort == "" {
-		sort = "created_at"
-	}
-	
-	order := strings.ToUpper(query.Get("order"))
-	if order != "ASC" && order != "DESC" {
-		order = "DESC"
-	}
-	
-	status := query.Get("status")
-
-	// Build filters
-	filters := map[string]interface{}{
-		"limit":  limit,
-		"offset": offset,
-		"sort":   sort,
-		"order":  order,
-	}
-	
-	if status != "" {
-		filters["status"] = status
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	ctx := r.Context()
-	userWords, err := h.userWordRepo.ListUserWords(ctx, userID, filters)
+	listQuery := repository.WordListQuery{
+		Search:      query.Get("q"),
+		Sort:        query.Get("sort"),
+		Desc:        !strings.EqualFold(query.Get("order"), "asc"),
+		PageRequest: page,
+		Filter: repository.WordFilter{
+			Status:   query.Get("status"),
+			TagQuery: query.Get("tags"),
+		},
+	}
+
+	// group_id=none lists the words outside any group
+	if groupID := query.Get("group_id"); groupID == "none" {
+		listQuery.Filter.Ungrouped = true
+	} else if groupID != "" {
+		id, err := uuid.Parse(groupID)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "invalid group id")
+			return
+		}
+		listQuery.Filter.GroupID = &id
+	}
+
+	if err := listQuery.Validate(); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	words, err := h.userWordRepo.ListWords(r.Context(), userID, listQuery)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "failed to list words")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"words": userWords,
-		"page":  page,
-		"limit": limit,
-	})
+	response := map[string]interface{}{
+		"words":       words.Words,
+		"next_cursor": words.NextCursor,
+		"limit":       listQuery.Limit,
+	}
+	if words.Total != nil {
+		response["total"] = *words.Total
+	}
+	respondJSON(w, http.StatusOK, response)
 }
 
 // Get returns a single word detail
@@ -307,6 +329,7 @@ func (h *WordsHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusInternalServerError, "failed to delete word")
 		return
 	}
+	h.invalidateDashboard(r)
 
 	respondJSON(w, http.StatusOK, map[string]string{
 		"message": "word deleted successfully",
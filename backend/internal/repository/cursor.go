@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidCursor is returned for page cursors that do not decode or were
+// issued for a different list order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page sizes of keyset-paginated lists
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// PageRequest selects one page of a keyset-paginated list
+type PageRequest struct {
+	// Cursor is the NextCursor of the previous page, empty for the first
+	Cursor string
+	Limit  int
+	// WithTotal also counts every row the list matches, which costs a
+	// second query; clients usually ask for it on the first page only
+	WithTotal bool
+}
+
+// normalize defaults and bounds the page size
+func (p *PageRequest) normalize() {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageSize
+	}
+	if p.Limit > MaxPageSize {
+		p.Limit = MaxPageSize
+	}
+}
+
+// keysetSort is a whitelisted sort key: a non-null SQL expression and the
+// type its text form is cast back to when comparing against a cursor, one
+// of timestamptz, numeric, integer or text
+type keysetSort struct {
+	expr string
+	cast string
+}
+
+// timestamptzLayouts are the text forms of timestamptz values in the ISO
+// date style, whose zone offset has minutes or seconds only when not whole
+var timestamptzLayouts = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999-07:00:00",
+}
+
+// numericKey matches the text form of finite numeric values
+var numericKey = regexp.MustCompile(`^-?(\d+(\.\d*)?|\.\d+)([eE][-+]?\d+)?$`)
+
+// validKey reports whether key is a text form the sort's cast accepts, so
+// that a tampered cursor is rejected here rather than failing the query
+func (s keysetSort) validKey(key string) bool {
+	switch s.cast {
+	case "timestamptz":
+		if key == "infinity" || key == "-infinity" {
+			return true
+		}
+		for _, layout := range timestamptzLayouts {
+			if _, err := time.Parse(layout, key); err == nil {
+				return true
+			}
+		}
+		return false
+	case "numeric":
+		return numericKey.MatchString(key) || key == "NaN" || key == "Infinity" || key == "-Infinity"
+	case "integer":
+		_, err := strconv.ParseInt(key, 10, 32)
+		return err == nil
+	case "text":
+		return !strings.ContainsRune(key, 0)
+	default:
+		return false
+	}
+}
+
+// keyset orders a list by a sort key with ties broken by a unique ID
+// column, and pages through it by the last row seen rather than by offset,
+// so that rows added meanwhile neither shift nor repeat later pages
+type keyset struct {
+	name     string
+	sort     keysetSort
+	idColumn string
+	desc     bool
+}
+
+// pageCursor is the opaque position after the last row of a page: the
+// row's sort key in text form and its ID, with the order it was listed in
+type pageCursor struct {
+	Sort string    `json:"s"`
+	Desc bool      `json:"d"`
+	Key  string    `json:"k"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// keyColumn selects the sort key in the text form stored in cursors
+func (k keyset) keyColumn() string {
+	return "(" + k.sort.expr + ")::text"
+}
+
+// orderBy returns the ORDER BY list of the keyset
+func (k keyset) orderBy() string {
+	direction := "ASC"
+	if k.desc {
+		direction = "DESC"
+	}
+	return k.sort.expr + " " + direction + ", " + k.idColumn + " " + direction
+}
+
+// decode decodes an encoded cursor, which must have been issued for the
+// same order and hold a sort key of the order's type
+func (k keyset) decode(encoded string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != k.name || cursor.Desc != k.desc {
+		return nil, fmt.Errorf("%w: it was issued for a different order", ErrInvalidCursor)
+	}
+	if !k.sort.validKey(cursor.Key) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// after returns the condition selecting the rows after an encoded cursor,
+// with placeholders numbered after the existing args, and the extended
+// args. An empty cursor selects every row.
+func (k keyset) after(encoded string, args []interface{}) (string, []interface{}, error) {
+	if encoded == "" {
+		return "TRUE", args, nil
+	}
+	cursor, err := k.decode(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+	args = append(args, cursor.Key, cursor.ID)
+	return fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d)", k.sort.expr, k.idColumn, op, len(args)-1, k.sort.cast, len(args)), args, nil
+}
+
+// cursor encodes the position after a row with the given sort key and ID
+func (k keyset) cursor(key string, id uuid.UUID) string {
+	data, _ := json.Marshal(pageCursor{Sort: k.name, Desc: k.desc, Key: key, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
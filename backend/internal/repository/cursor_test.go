@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestKeysetCursorRoundTrip(t *testing.T) {
+	id := uuid.MustParse("6f1c2b9e-3d4a-4e5f-8a7b-1c2d3e4f5a6b")
+
+	tests := []struct {
+		name    string
+		order   keyset
+		key     string
+		wantSQL string
+	}{
+		{
+			name:    "timestamptz descending",
+			order:   keyset{name: WordSortCreatedAt, sort: wordSorts[WordSortCreatedAt], idColumn: "uw.id", desc: true},
+			key:     "2026-10-16 12:34:56.789012+00",
+			wantSQL: "(uw.created_at, uw.id) < ($2::timestamptz, $3)",
+		},
+		{
+			name:    "timestamptz with a half hour offset",
+			order:   keyset{name: WordSortCreatedAt, sort: wordSorts[WordSortCreatedAt], idColumn: "uw.id"},
+			key:     "2026-10-16 18:04:56+05:30",
+			wantSQL: "(uw.created_at, uw.id) > ($2::timestamptz, $3)",
+		},
+		{
+			name:    "infinity",
+			order:   keyset{name: WordSortNextReviewAt, sort: wordSorts[WordSortNextReviewAt], idColumn: "uw.id"},
+			key:     "infinity",
+			wantSQL: "(COALESCE(uw.next_review_at, 'infinity'), uw.id) > ($2::timestamptz, $3)",
+		},
+		{
+			name:    "numeric",
+			order:   keyset{name: WordSortEase, sort: wordSorts[WordSortEase], idColumn: "uw.id"},
+			key:     "2.36",
+			wantSQL: "(COALESCE(uw.easiness_factor, 2.5), uw.id) > ($2::numeric, $3)",
+		},
+		{
+			name:    "integer",
+			order:   keyset{name: WordSortLapses, sort: wordSorts[WordSortLapses], idColumn: "uw.id", desc: true},
+			key:     "-3",
+			wantSQL: "(uw.lapses, uw.id) < ($2::integer, $3)",
+		},
+		{
+			name:    "text",
+			order:   keyset{name: WordSortWord, sort: wordSorts[WordSortWord], idColumn: "uw.id"},
+			key:     `naïve "quoted" word`,
+			wantSQL: "(lower(w.word), uw.id) > ($2::text, $3)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := tt.order.cursor(tt.key, id)
+
+			cursor, err := tt.order.decode(encoded)
+			if err != nil {
+				t.Fatalf("decode error: %v", err)
+			}
+			want := pageCursor{Sort: tt.order.name, Desc: tt.order.desc, Key: tt.key, ID: id}
+			if *cursor != want {
+				t.Errorf("decoded %+v, want %+v", *cursor, want)
+			}
+
+			sql, args, err := tt.order.after(encoded, []interface{}{"user"})
+			if err != nil {
+				t.Fatalf("after error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %s, want %s", sql, tt.wantSQL)
+			}
+			if wantArgs := []interface{}{"user", tt.key, id}; !reflect.DeepEqual(args, wantArgs) {
+				t.Errorf("args = %v, want %v", args, wantArgs)
+			}
+		})
+	}
+}
+
+func TestKeysetAfterWithoutCursor(t *testing.T) {
+	sql, args, err := reviewHistoryOrder.after("", []interface{}{"user"})
+	if err != nil || sql != "TRUE" || len(args) != 1 {
+		t.Errorf("after(\"\") = %q, %v, %v; want TRUE with args unchanged", sql, args, err)
+	}
+}
+
+func TestKeysetDecodeRejectsTamperedCursors(t *testing.T) {
+	created := keyset{name: WordSortCreatedAt, sort: wordSorts[WordSortCreatedAt], idColumn: "uw.id", desc: true}
+	ease := keyset{name: WordSortEase, sort: wordSorts[WordSortEase], idColumn: "uw.id"}
+	lapses := keyset{name: WordSortLapses, sort: wordSorts[WordSortLapses], idColumn: "uw.id"}
+	word := keyset{name: WordSortWord, sort: wordSorts[WordSortWord], idColumn: "uw.id"}
+	id := `"6f1c2b9e-3d4a-4e5f-8a7b-1c2d3e4f5a6b"`
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+
+	tests := []struct {
+		name    string
+		order   keyset
+		encoded string
+	}{
+		{"not base64", created, "not a cursor!"},
+		{"not JSON", created, encode("created_at")},
+		{"other sort", ease, created.cursor("2026-10-16 12:00:00+00", uuid.New())},
+		{"other direction", keyset{name: created.name, sort: created.sort, idColumn: created.idColumn}, created.cursor("2026-10-16 12:00:00+00", uuid.New())},
+		{"key not a timestamp", created, encode(`{"s":"created_at","d":true,"k":"x","id":` + id + `}`)},
+		{"key a date only", created, encode(`{"s":"created_at","d":true,"k":"2026-10-16","id":` + id + `}`)},
+		{"key not a string", created, encode(`{"s":"created_at","d":true,"k":1,"id":` + id + `}`)},
+		{"key not a number", ease, encode(`{"s":"ease","d":false,"k":"2.5; DROP","id":` + id + `}`)},
+		{"hex number", ease, encode(`{"s":"ease","d":false,"k":"0x1p1","id":` + id + `}`)},
+		{"fractional integer", lapses, encode(`{"s":"lapses","d":false,"k":"1.5","id":` + id + `}`)},
+		{"integer out of range", lapses, encode(`{"s":"lapses","d":false,"k":"4294967296","id":` + id + `}`)},
+		{"text with NUL", word, encode(`{"s":"word","d":false,"k":"a\u0000b","id":` + id + `}`)},
+		{"bad ID", created, encode(`{"s":"created_at","d":true,"k":"2026-10-16 12:00:00+00","id":"x"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.order.decode(tt.encoded); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decode error = %v, want %v", err, ErrInvalidCursor)
+			}
+			if _, _, err := tt.order.after(tt.encoded, nil); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("after error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DayStats is one user's daily_stats row: their activity on one study day
+type DayStats struct {
+	Date             time.Time `json:"date"`
+	NewWords         int       `json:"new_words"`
+	Reviewed         int       `json:"reviewed"`
+	Mastered         int       `json:"mastered"`
+	XPGained         int       `json:"xp_gained"`
+	TimeSpentSeconds int       `json:"time_spent_seconds"`
+	// StreakDays is the number of consecutive days with reviews ending on
+	// this day, 0 when the day had none
+	StreakDays int `json:"streak_days"`
+	// ReviewGoal is nil for days rolled up before the user had a profile goal
+	ReviewGoal *int `json:"review_goal"`
+}
+
+// dailyStatsSQL computes the user's ($1) daily stats for every study date
+// from $4 to $5, inclusive, from review_logs and user_words, with days in
+// time zone $2 starting at rollover hour $3. $6 and $7 are the start of the
+// first day and the end of the last, and $8 is the interval from which
+// words count as mastered. Days without activity have a row too.
+var dailyStatsSQL = `
+	WITH days AS (
+		SELECT d::date AS stat_date
+		FROM generate_series($4::date, $5::date, '1 day') d
+	),
+	logs AS (
+		SELECT
+			rl.reviewed_at,
+			rl.xp_awarded,
+			rl.response_time_ms,
+			rl.interval,
+			COALESCE(rl.prev_interval, LAG(rl.interval) OVER w, 0) AS prev_interval
+		FROM review_logs rl
+		JOIN review_cards rc ON rl.card_id = rc.id
+		WHERE rc.user_id = $1
+		WINDOW w AS (PARTITION BY rl.card_id ORDER BY rl.reviewed_at, rl.created_at)
+	),
+	reviews AS (
+		SELECT
+			` + studyDateSQL("reviewed_at", "$2", "$3") + ` AS stat_date,
+			COUNT(*) AS reviewed,
+			COUNT(*) FILTER (WHERE interval >= $8 AND prev_interval < $8) AS mastered,
+			SUM(xp_awarded) AS xp_gained,
+			SUM(COALESCE(response_time_ms, 0)) / 1000 AS time_spent_seconds
+		FROM logs
+		WHERE reviewed_at >= $6 AND reviewed_at < $7
+		GROUP BY 1
+	),
+	-- Consecutive active dates share the same date - row number
+	active AS (
+		SELECT DISTINCT ` + studyDateSQL("reviewed_at", "$2", "$3") + ` AS stat_date
+		FROM logs
+		WHERE reviewed_at < $7
+	),
+	streaks AS (
+		SELECT stat_date, ROW_NUMBER() OVER (PARTITION BY grp ORDER BY stat_date) AS streak_days
+		FROM (
+			SELECT stat_date, stat_date - (ROW_NUMBER() OVER (ORDER BY stat_date))::int AS grp
+			FROM active
+		) runs
+	),
+	added AS (
+		SELECT ` + studyDateSQL("uw.created_at", "$2", "$3") + ` AS stat_date, COUNT(*) AS new_words
+		FROM user_words uw
+		WHERE uw.user_id = $1
+		  AND uw.created_at >= $6 AND uw.created_at < $7
+		GROUP BY 1
+	)
+	SELECT
+		p.user_id,
+		days.stat_date,
+		COALESCE(a.new_words, 0) AS new_words,
+		COALESCE(r.reviewed, 0) AS reviewed,
+		COALESCE(r.mastered, 0) AS mastered,
+		COALESCE(r.xp_gained, 0) AS xp_gained,
+		COALESCE(r.time_spent_seconds, 0) AS time_spent_seconds,
+		COALESCE(s.streak_days, 0) AS streak_days,
+		p.daily_review_goal AS review_goal
+	FROM days
+	JOIN profiles p ON p.user_id = $1
+	LEFT JOIN reviews r ON r.stat_date = days.stat_date
+	LEFT JOIN added a ON a.stat_date = days.stat_date
+	LEFT JOIN streaks s ON s.stat_date = days.stat_date
+`
+
+// dailyStatsArgs are the args of dailyStatsSQL for the study dates from from
+// to to, inclusive
+func dailyStatsArgs(userID string, boundary DayBoundary, from, to time.Time) []interface{} {
+	return []interface{}{
+		userID,
+		boundary.Timezone,
+		boundary.RolloverHour,
+		from,
+		to,
+		boundary.DayStart(from),
+		boundary.DayStart(to.AddDate(0, 0, 1)),
+		MatureInterval,
+	}
+}
+
+// rollupDailyStats recomputes the user's daily_stats rows for every study
+// date from from to to, inclusive. Rows are written for days without
+// activity too, so that a missing row means the day has not been rolled up.
+// Rolling a day up again overwrites it, except that past days keep the
+// review goal they were first recorded with.
+func rollupDailyStats(ctx context.Context, db *DB, userID string, boundary DayBoundary, from, to time.Time) error {
+	today := boundary.StudyDate(time.Now())
+
+	query := `
+		INSERT INTO daily_stats (
+			user_id, stat_date, new_words, reviewed, mastered, xp_gained,
+			time_spent_seconds, streak_days, review_goal, updated_at
+		)
+		SELECT
+			c.user_id, c.stat_date, c.new_words, c.reviewed, c.mastered, c.xp_gained,
+			c.time_spent_seconds, c.streak_days, c.review_goal, NOW()
+		FROM (` + dailyStatsSQL + `) c
+		ON CONFLICT (user_id, stat_date) DO UPDATE SET
+			new_words = EXCLUDED.new_words,
+			reviewed = EXCLUDED.reviewed,
+			mastered = EXCLUDED.mastered,
+			xp_gained = EXCLUDED.xp_gained,
+			time_spent_seconds = EXCLUDED.time_spent_seconds,
+			streak_days = EXCLUDED.streak_days,
+			review_goal = CASE
+				WHEN daily_stats.stat_date >= $9 THEN EXCLUDED.review_goal
+				ELSE COALESCE(daily_stats.review_goal, EXCLUDED.review_goal)
+			END,
+			updated_at = NOW()
+	`
+
+	args := append(dailyStatsArgs(userID, boundary, from, to), today)
+	if _, err := db.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to roll up daily stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetDailyStats returns the user's daily stats for the study dates from from
+// to to, inclusive, oldest first. Days are read from daily_stats, which the
+// stats aggregator keeps current; days up to today that it has not rolled
+// up yet are computed on the fly without being stored.
+func (r *StatsRepository) GetDailyStats(ctx context.Context, userID string, from, to time.Time) ([]DayStats, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	today := boundary.StudyDate(time.Now())
+	if to.After(today) {
+		to = today
+	}
+	if from.After(to) {
+		return nil, nil
+	}
+
+	var firstMissing *time.Time
+	missingQuery := `
+		SELECT MIN(d::date)
+		FROM generate_series($2::date, $3::date, '1 day') d
+		WHERE NOT EXISTS (
+			SELECT 1 FROM daily_stats ds
+			WHERE ds.user_id = $1 AND ds.stat_date = d::date
+		)
+	`
+	if err := r.db.Pool.QueryRow(ctx, missingQuery, userID, from, to).Scan(&firstMissing); err != nil {
+		return nil, fmt.Errorf("failed to find missing daily stats: %w", err)
+	}
+
+	query := `
+		SELECT stat_date, new_words, reviewed, mastered, xp_gained, time_spent_seconds, streak_days, review_goal
+		FROM daily_stats
+		WHERE user_id = $1
+		  AND stat_date BETWEEN $2 AND $3
+		ORDER BY stat_date
+	`
+	args := []interface{}{userID, from, to}
+	if firstMissing != nil {
+		// Stored days from $9, plus the days from the first missing one
+		// that are still missing
+		query = `
+			SELECT stat_date, new_words, reviewed, mastered, xp_gained, time_spent_seconds, streak_days, review_goal
+			FROM daily_stats
+			WHERE user_id = $1
+			  AND stat_date BETWEEN $9 AND $5
+			UNION ALL
+			SELECT c.stat_date, c.new_words, c.reviewed, c.mastered, c.xp_gained, c.time_spent_seconds, c.streak_days, c.review_goal
+			FROM (` + dailyStatsSQL + `) c
+			WHERE NOT EXISTS (
+				SELECT 1 FROM daily_stats ds
+				WHERE ds.user_id = $1 AND ds.stat_date = c.stat_date
+			)
+			ORDER BY stat_date
+		`
+		args = append(dailyStatsArgs(userID, boundary, *firstMissing, to), from)
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	var days []DayStats
+	for rows.Next() {
+		var day DayStats
+		err := rows.Scan(
+			&day.Date,
+			&day.NewWords,
+			&day.Reviewed,
+			&day.Mastered,
+			&day.XPGained,
+			&day.TimeSpentSeconds,
+			&day.StreakDays,
+			&day.ReviewGoal,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan daily stats: %w", err)
+		}
+		days = append(days, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating daily stats: %w", err)
+	}
+
+	return days, nil
+}
+
+// GetStudyDate returns the date of the user's current study day
+func (r *StatsRepository) GetStudyDate(ctx context.Context, userID string) (time.Time, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return boundary.StudyDate(time.Now()), nil
+}
+
+// RollupDailyStats recomputes the user's daily_stats for the last days study
+// days up to and including today, or for their whole history when days is 0.
+// It is idempotent and returns the number of days rolled up.
+func (r *StatsRepository) RollupDailyStats(ctx context.Context, userID string, days int) (int, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return 0, err
+	}
+	today := boundary.StudyDate(time.Now())
+
+	from := today.AddDate(0, 0, 1-days)
+	if days <= 0 {
+		query := `
+			SELECT LEAST(
+				(SELECT MIN(rl.reviewed_at) FROM review_logs rl JOIN review_cards rc ON rl.card_id = rc.id WHERE rc.user_id = $1),
+				(SELECT MIN(uw.created_at) FROM user_words uw WHERE uw.user_id = $1)
+			)
+		`
+		var firstActivity *time.Time
+		if err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&firstActivity); err != nil {
+			return 0, fmt.Errorf("failed to get first activity: %w", err)
+		}
+		if firstActivity == nil {
+			return 0, nil
+		}
+		from = boundary.StudyDate(*firstActivity)
+	}
+	if from.After(today) {
+		return 0, nil
+	}
+
+	if err := rollupDailyStats(ctx, r.db, userID, boundary, from, today); err != nil {
+		return 0, err
+	}
+
+	return int(today.Sub(from).Hours()/24) + 1, nil
+}
+
+// ListStatsUsers returns every user with a profile
+func (r *StatsRepository) ListStatsUsers(ctx context.Context) ([]string, error) {
+	return r.queryUserIDs(ctx, `SELECT user_id FROM profiles ORDER BY user_id`)
+}
+
+// ListActiveUsers returns the users whose cards were reviewed or whose words
+// were added or changed since since
+func (r *StatsRepository) ListActiveUsers(ctx context.Context, since time.Time) ([]string, error) {
+	query := `
+		SELECT user_id FROM review_cards WHERE updated_at >= $1
+		UNION
+		SELECT user_id FROM user_words WHERE updated_at >= $1 OR created_at >= $1
+		ORDER BY user_id
+	`
+	return r.queryUserIDs(ctx, query, since)
+}
+
+func (r *StatsRepository) queryUserIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// DefaultRolloverHour is the local hour a study day starts at when the
+// profile does not set one, matching the profiles column default
+const DefaultRolloverHour = 4
+
+// DayBoundary describes where a user's study days begin: at RolloverHour
+// local time in Timezone. Reviews made before the rollover hour count
+// towards the previous day.
+type DayBoundary struct {
+	Timezone     string `json:"timezone"`
+	RolloverHour int    `json:"day_rollover_hour"`
+}
+
+// Location returns the boundary's time zone, falling back to UTC for
+// unknown zone names
+func (b DayBoundary) Location() *time.Location {
+	if b.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// StudyDate returns the study day t belongs to, as midnight UTC of that date
+func (b DayBoundary) StudyDate(t time.Time) time.Time {
+	local := t.In(b.Location()).Add(-time.Duration(b.RolloverHour) * time.Hour)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DayStart returns the instant the study day with the given date begins
+func (b DayBoundary) DayStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), b.RolloverHour, 0, 0, 0, b.Location())
+}
+
+// Today returns the start and end of the study day containing now
+func (b DayBoundary) Today(now time.Time) (time.Time, time.Time) {
+	date := b.StudyDate(now)
+	return b.DayStart(date), b.DayStart(date.AddDate(0, 0, 1))
+}
+
+// studyDateSQL converts a timestamptz column into the user's study date;
+// tzParam and hourParam are the placeholders bound to the boundary's
+// Timezone and RolloverHour
+func studyDateSQL(column, tzParam, hourParam string) string {
+	return fmt.Sprintf("((%s AT TIME ZONE %s) - make_interval(hours => %s))::date", column, tzParam, hourParam)
+}
+
+// getDayBoundary loads a user's time zone and rollover hour from their profile.
+// Users without a profile get UTC and DefaultRolloverHour.
+func getDayBoundary(ctx context.Context, db *DB, userID string) (DayBoundary, error) {
+	query := `
+		SELECT COALESCE(timezone, 'UTC'), day_rollover_hour
+		FROM profiles
+		WHERE user_id = $1
+	`
+
+	var boundary DayBoundary
+	err := db.Pool.QueryRow(ctx, query, userID).Scan(&boundary.Timezone, &boundary.RolloverHour)
+	if err == pgx.ErrNoRows {
+		return DayBoundary{Timezone: "UTC", RolloverHour: DefaultRolloverHour}, nil
+	}
+	if err != nil {
+		return DayBoundary{}, fmt.Errorf("failed to get day boundary: %w", err)
+	}
+
+	// Postgres rejects unknown zone names in AT TIME ZONE, so normalise here
+	if _, err := time.LoadLocation(boundary.Timezone); err != nil {
+		boundary.Timezone = "UTC"
+	}
+
+	return boundary, nil
+}
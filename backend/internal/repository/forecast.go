@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MatureInterval is the interval in days from which a card counts as mature
+// rather than young
+const MatureInterval = 21
+
+// DueForecast counts the user's studied cards by the study day they come due
+type DueForecast struct {
+	// Today is the date of the user's current study day (midnight UTC) and
+	// TodayStart the instant it began
+	Today      time.Time
+	TodayStart time.Time
+	Counts     []DueCount
+}
+
+// DueCount is the number of cards of one group and maturity due on one day
+type DueCount struct {
+	// Day is the number of study days after today; overdue cards are due today
+	Day       int
+	GroupID   *uuid.UUID
+	GroupName string
+	Mature    bool
+	Cards     int
+}
+
+// GetDueForecast counts the user's unsuspended studied cards coming due on
+// each of the next days, counting in the user's study days. Overdue cards
+// count towards today and buried cards towards tomorrow at the earliest.
+func (r *ReviewRepository) GetDueForecast(ctx context.Context, userID string, days int) (*DueForecast, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	today := boundary.StudyDate(now)
+	todayStart, _ := boundary.Today(now)
+
+	query := `
+		WITH due AS (
+			SELECT
+				GREATEST(
+					` + studyDateSQL("rc.next_review_at", "$2", "$3") + ` - $4::date,
+					CASE WHEN uw.buried_until > NOW() THEN 1 ELSE 0 END
+				) AS day,
+				uw.group_id,
+				rc.interval >= $6 AS mature
+			FROM review_cards rc
+			JOIN user_words uw ON rc.user_word_id = uw.id
+			WHERE rc.user_id = $1
+			  AND rc.card_state <> 'new'
+			  AND rc.next_review_at IS NOT NULL
+			  AND uw.suspended_at IS NULL
+		)
+		SELECT due.day, due.group_id, COALESCE(g.name, ''), due.mature, COUNT(*)
+		FROM due
+		LEFT JOIN groups g ON due.group_id = g.id
+		WHERE due.day < $5
+		GROUP BY due.day, due.group_id, g.name, due.mature
+		ORDER BY due.day, g.name NULLS LAST, due.group_id
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, boundary.Timezone, boundary.RolloverHour, today, days, MatureInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due forecast: %w", err)
+	}
+	defer rows.Close()
+
+	forecast := &DueForecast{Today: today, TodayStart: todayStart}
+	for rows.Next() {
+		var count DueCount
+		if err := rows.Scan(&count.Day, &count.GroupID, &count.GroupName, &count.Mature, &count.Cards); err != nil {
+			return nil, fmt.Errorf("failed to scan due forecast: %w", err)
+		}
+		forecast.Counts = append(forecast.Counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating due forecast: %w", err)
+	}
+
+	return forecast, nil
+}
+
+// CountNewCards counts the user's reviewable cards that have never been studied
+func (r *ReviewRepository) CountNewCards(ctx context.Context, userID string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.card_state = 'new'
+		  AND ` + reviewableWordSQL
+
+	var count int
+	if err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count new cards: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrGroupNameTaken is returned when a user already has a group with the name
+var ErrGroupNameTaken = errors.New("a group with this name already exists")
+
+// uniqueViolation is the Postgres error code for unique constraint violations
+const uniqueViolation = "23505"
+
+// Group is a user's folder of words with its word counts
+type Group struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	SortOrder int       `json:"sort_order"`
+	WordCount int       `json:"word_count"`
+	// DueCount is the number of the group's reviewable cards, new cards
+	// excluded, due before the user's current study day ends
+	DueCount  int       `json:"due_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GroupUpdate holds the fields of a group to change; nil fields are kept
+type GroupUpdate struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+type GroupRepository struct {
+	db *DB
+}
+
+func NewGroupRepository(db *DB) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+// groupColumns are the groups (g) columns and counts scanned by scanGroups;
+// $1 is the user ID and $2 the end of their current study day
+const groupColumns = `
+	g.id,
+	g.name,
+	COALESCE(g.color, ''),
+	COALESCE(g.sort_order, 0),
+	(SELECT COUNT(*) FROM user_words uw WHERE uw.group_id = g.id),
+	(
+		SELECT COUNT(*)
+		FROM review_cards rc
+		JOIN user_words uw ON rc.user_word_id = uw.id
+		WHERE uw.group_id = g.id
+		  AND rc.card_state <> 'new'
+		  AND rc.next_review_at < $2
+		  AND uw.suspended_at IS NULL
+		  AND (uw.buried_until IS NULL OR uw.buried_until < $2)
+	),
+	g.created_at,
+	g.updated_at
+`
+
+// ListGroups returns the user's groups in their sort order, with counts
+func (r *GroupRepository) ListGroups(ctx context.Context, userID string) ([]Group, error) {
+	return r.queryGroups(ctx, userID, `ORDER BY g.sort_order, g.created_at, g.id`)
+}
+
+// GetGroup returns one of the user's groups with counts, or nil if the user
+// has no such group
+func (r *GroupRepository) GetGroup(ctx context.Context, userID string, groupID uuid.UUID) (*Group, error) {
+	groups, err := r.queryGroups(ctx, userID, `AND g.id = $3`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	return &groups[0], nil
+}
+
+// queryGroups selects the user's groups with groupColumns; rest follows the
+// WHERE clause and may refer to extra arguments from $3
+func (r *GroupRepository) queryGroups(ctx context.Context, userID, rest string, extra ...interface{}) ([]Group, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, dayEnd := boundary.Today(time.Now())
+
+	query := `
+		SELECT ` + groupColumns + `
+		FROM groups g
+		WHERE g.user_id = $1
+		` + rest
+
+	args := append([]interface{}{userID, dayEnd}, extra...)
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []Group{}
+	for rows.Next() {
+		var group Group
+		err := rows.Scan(
+			&group.ID,
+			&group.Name,
+			&group.Color,
+			&group.SortOrder,
+			&group.WordCount,
+			&group.DueCount,
+			&group.CreatedAt,
+			&group.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// CreateGroup adds a group after the user's existing groups
+func (r *GroupRepository) CreateGroup(ctx context.Context, userID, name, color string) (*Group, error) {
+	query := `
+		INSERT INTO groups (user_id, name, color, sort_order)
+		SELECT $1, $2, $3, COALESCE(MAX(sort_order) + 1, 0)
+		FROM groups
+		WHERE user_id = $1
+		RETURNING id
+	`
+
+	var groupID uuid.UUID
+	err := r.db.Pool.QueryRow(ctx, query, userID, name, color).Scan(&groupID)
+	if isUniqueViolation(err) {
+		return nil, ErrGroupNameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	return r.GetGroup(ctx, userID, groupID)
+}
+
+// UpdateGroup renames or recolours one of the user's groups. It returns nil
+// if the user has no such group.
+func (r *GroupRepository) UpdateGroup(ctx context.Context, userID string, groupID uuid.UUID, update GroupUpdate) (*Group, error) {
+	query := `
+		UPDATE groups
+		SET
+			name = COALESCE($3, name),
+			color = COALESCE($4, color),
+			updated_at = NOW()
+		WHERE user_id = $1 AND id = $2
+		RETURNING id
+	`
+
+	var id uuid.UUID
+	err := r.db.Pool.QueryRow(ctx, query, userID, groupID, update.Name, update.Color).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrGroupNameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update group: %w", err)
+	}
+
+	return r.GetGroup(ctx, userID, groupID)
+}
+
+// DeleteGroup removes one of the user's groups; its words are kept,
+// ungrouped. It reports whether the group existed.
+func (r *GroupRepository) DeleteGroup(ctx context.Context, userID string, groupID uuid.UUID) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM groups WHERE user_id = $1 AND id = $2`, userID, groupID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete group: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ReorderGroups renumbers the user's groups so that those in groupIDs come
+// first, in that order, followed by the rest in their current order. It
+// returns the number of groupIDs that are the user's; unless that is all of
+// them nothing is changed.
+func (r *GroupRepository) ReorderGroups(ctx context.Context, userID string, groupIDs []uuid.UUID) (int, error) {
+	query := `
+		WITH ordered AS (
+			SELECT
+				g.id,
+				ids.position IS NOT NULL AS listed,
+				ROW_NUMBER() OVER (ORDER BY ids.position NULLS LAST, g.sort_order, g.created_at, g.id) - 1 AS sort_order
+			FROM groups g
+			LEFT JOIN unnest($2::uuid[]) WITH ORDINALITY AS ids(id, position) ON ids.id = g.id
+			WHERE g.user_id = $1
+		),
+		found AS (
+			SELECT COUNT(*) FILTER (WHERE listed) AS listed FROM ordered
+		),
+		reordered AS (
+			UPDATE groups g
+			SET sort_order = o.sort_order, updated_at = NOW()
+			FROM ordered o, found f
+			WHERE g.id = o.id
+			  AND f.listed = cardinality($2::uuid[])
+		)
+		SELECT listed FROM found
+	`
+
+	var found int
+	if err := r.db.Pool.QueryRow(ctx, query, userID, groupIDs).Scan(&found); err != nil {
+		return 0, fmt.Errorf("failed to reorder groups: %w", err)
+	}
+	return found, nil
+}
+
+// MoveWords puts the words selected by filter into the group, or takes them
+// out of their group when groupID is nil. It returns the number of words
+// matched; the caller checks that the group is the user's.
+func (r *GroupRepository) MoveWords(ctx context.Context, userID string, groupID *uuid.UUID, filter WordFilter) (int, error) {
+	where, args, err := filter.conditions([]interface{}{userID, groupID})
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		UPDATE user_words uw
+		SET group_id = $2, updated_at = NOW()
+		WHERE uw.user_id = $1
+		  AND ` + where
+
+	result, err := r.db.Pool.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move words: %w", err)
+	}
+	return int(result.RowsAffected()), nil
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Leech is a word whose cards keep being forgotten
+type Leech struct {
+	UserWordID       uuid.UUID      `json:"user_word_id"`
+	WordID           uuid.UUID      `json:"word_id"`
+	Word             string         `json:"word"`
+	Phonetic         string         `json:"phonetic"`
+	Definitions      string         `json:"definitions"` // JSONB as string
+	ContextSentence  string         `json:"context_sentence"`
+	Mnemonic         string         `json:"mnemonic"`
+	CustomDefinition string         `json:"custom_definition"`
+	Lapses           int            `json:"lapses"`
+	CardLapses       map[string]int `json:"card_lapses"`
+	SuspendedAt      *time.Time     `json:"suspended_at"`
+	LastReviewedAt   *time.Time     `json:"last_reviewed_at"`
+}
+
+// markLeech flags a word as a leech within tx once one of its cards reaches
+// the user's leech threshold, suspending it at reviewedAt when the user's
+// leech action is to suspend. It reports whether the word became a leech and
+// whether it was suspended.
+func markLeech(ctx context.Context, tx pgx.Tx, userWordID uuid.UUID, reviewedAt time.Time) (bool, bool, error) {
+	query := `
+		UPDATE user_words uw
+		SET
+			is_leech = TRUE,
+			suspended_at = CASE
+				WHEN p.leech_action = 'suspend' THEN COALESCE(uw.suspended_at, $2)
+				ELSE uw.suspended_at
+			END,
+			updated_at = NOW()
+		FROM profiles p
+		WHERE uw.id = $1
+		  AND p.user_id = uw.user_id
+		  AND NOT uw.is_leech
+		  AND EXISTS (
+			SELECT 1 FROM review_cards rc
+			WHERE rc.user_word_id = uw.id
+			  AND rc.lapses >= p.leech_threshold
+		  )
+		RETURNING p.leech_action = 'suspend' AND uw.suspended_at = $2
+	`
+
+	var suspended bool
+	err := tx.QueryRow(ctx, query, userWordID, reviewedAt).Scan(&suspended)
+	if err == pgx.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("failed to mark leech: %w", err)
+	}
+
+	return true, suspended, nil
+}
+
+// unmarkLeech clears a word's leech flag within tx when none of its cards is
+// at the user's threshold any more, lifting the suspension if the leech
+// caused it
+func unmarkLeech(ctx context.Context, tx pgx.Tx, userWordID uuid.UUID, unsuspend bool) error {
+	query := `
+		UPDATE user_words uw
+		SET
+			is_leech = FALSE,
+			suspended_at = CASE WHEN $2 THEN NULL ELSE uw.suspended_at END,
+			updated_at = NOW()
+		FROM profiles p
+		WHERE uw.id = $1
+		  AND p.user_id = uw.user_id
+		  AND uw.is_leech
+		  AND NOT EXISTS (
+			SELECT 1 FROM review_cards rc
+			WHERE rc.user_word_id = uw.id
+			  AND rc.lapses >= p.leech_threshold
+		  )
+	`
+
+	if _, err := tx.Exec(ctx, query, userWordID, unsuspend); err != nil {
+		return fmt.Errorf("failed to unmark leech: %w", err)
+	}
+
+	return nil
+}
+
+// reevaluateLeeches marks or unmarks within tx each of the user's words whose
+// leech flag no longer matches the user's threshold, as after the threshold
+// changed. Words suspended as leeches are unsuspended when unmarked; words
+// newly marked are suspended at now if the user's leech action says so.
+func reevaluateLeeches(ctx context.Context, tx pgx.Tx, userID string, now time.Time) error {
+	query := `
+		SELECT
+			uw.id,
+			uw.is_leech,
+			EXISTS (
+				SELECT 1 FROM review_logs rl
+				WHERE rl.user_word_id = uw.id
+				  AND rl.leech_suspended
+				  AND rl.reviewed_at = uw.suspended_at
+			)
+		FROM user_words uw
+		JOIN profiles p ON p.user_id = uw.user_id
+		WHERE uw.user_id = $1
+		  AND uw.is_leech <> EXISTS (
+			SELECT 1 FROM review_cards rc
+			WHERE rc.user_word_id = uw.id
+			  AND rc.lapses >= p.leech_threshold
+		  )
+	`
+
+	type staleLeech struct {
+		userWordID       uuid.UUID
+		isLeech          bool
+		suspendedAsLeech bool
+	}
+
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find stale leech flags: %w", err)
+	}
+	var stale []staleLeech
+	for rows.Next() {
+		var word staleLeech
+		if err := rows.Scan(&word.userWordID, &word.isLeech, &word.suspendedAsLeech); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan stale leech flag: %w", err)
+		}
+		stale = append(stale, word)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating stale leech flags: %w", err)
+	}
+
+	for _, word := range stale {
+		if word.isLeech {
+			err = unmarkLeech(ctx, tx, word.userWordID, word.suspendedAsLeech)
+		} else {
+			_, _, err = markLeech(ctx, tx, word.userWordID, now)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leechColumns are the user_words (uw) and words (w) columns scanned by scanLeech
+const leechColumns = `
+	uw.id,
+	w.id,
+	w.word,
+	w.phonetic,
+	w.definitions::text,
+	COALESCE(uw.context_sentence, ''),
+	COALESCE(uw.mnemonic, ''),
+	COALESCE(uw.custom_definition, ''),
+	uw.lapses,
+	(SELECT jsonb_object_agg(rc.card_type, rc.lapses) FROM review_cards rc WHERE rc.user_word_id = uw.id),
+	uw.suspended_at,
+	uw.last_reviewed_at
+`
+
+// scanLeech scans a row selected with leechColumns
+func scanLeech(row pgx.Row) (*Leech, error) {
+	var leech Leech
+	err := row.Scan(
+		&leech.UserWordID,
+		&leech.WordID,
+		&leech.Word,
+		&leech.Phonetic,
+		&leech.Definitions,
+		&leech.ContextSentence,
+		&leech.Mnemonic,
+		&leech.CustomDefinition,
+		&leech.Lapses,
+		&leech.CardLapses,
+		&leech.SuspendedAt,
+		&leech.LastReviewedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &leech, nil
+}
+
+// GetLeeches returns the user's leeches, most lapses first
+func (r *ReviewRepository) GetLeeches(ctx context.Context, userID string) ([]Leech, error) {
+	query := `
+		SELECT ` + leechColumns + `
+		FROM user_words uw
+		JOIN words w ON uw.word_id = w.id
+		WHERE uw.user_id = $1
+		  AND uw.is_leech
+		ORDER BY uw.lapses DESC, uw.last_reviewed_at DESC NULLS LAST
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leeches: %w", err)
+	}
+	defer rows.Close()
+
+	leeches := []Leech{}
+	for rows.Next() {
+		leech, err := scanLeech(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leech: %w", err)
+		}
+		leeches = append(leeches, *leech)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leeches: %w", err)
+	}
+
+	return leeches, nil
+}
+
+// UpdateWordNotes sets the mnemonic and custom definition of one of the
+// user's words; nil leaves a field unchanged and an empty string clears it.
+// It returns nil if the user has no such word.
+func (r *ReviewRepository) UpdateWordNotes(ctx context.Context, userID string, userWordID uuid.UUID, mnemonic, customDefinition *string) (*Leech, error) {
+	query := `
+		WITH updated AS (
+			UPDATE user_words
+			SET
+				mnemonic = CASE WHEN $3::text IS NULL THEN mnemonic ELSE NULLIF($3, '') END,
+				custom_definition = CASE WHEN $4::text IS NULL THEN custom_definition ELSE NULLIF($4, '') END,
+				updated_at = NOW()
+			WHERE id = $2 AND user_id = $1
+			RETURNING *
+		)
+		SELECT ` + leechColumns + `
+		FROM updated uw
+		JOIN words w ON uw.word_id = w.id
+	`
+
+	leech, err := scanLeech(r.db.Pool.QueryRow(ctx, query, userID, userWordID, mnemonic, customDefinition))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update word notes: %w", err)
+	}
+
+	return leech, nil
+}
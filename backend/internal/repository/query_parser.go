@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Bounds on boolean queries, so that a query cannot build an unbounded statement
+const (
+	maxQueryTerms = 20
+	maxQueryDepth = 10
+)
+
+// queryExpr is a node of a parsed boolean query such as
+// `tag:gre AND NOT (tag:done OR tag:"phrasal verbs")`
+type queryExpr interface {
+	// sql returns the node's condition on user_words (uw), with
+	// placeholders numbered after the existing args, and the extended args
+	sql(args []interface{}) (string, []interface{})
+}
+
+// queryNot negates an expression. Conditions on nullable columns are NULL
+// rather than false for rows without a value, so the negation treats NULL
+// as false: -group:Novel still matches ungrouped words.
+type queryNot struct {
+	expr queryExpr
+}
+
+func (n queryNot) sql(args []interface{}) (string, []interface{}) {
+	cond, args := n.expr.sql(args)
+	return "NOT COALESCE(" + cond + ", FALSE)", args
+}
+
+// queryBinary joins two expressions with AND or OR
+type queryBinary struct {
+	op          string
+	left, right queryExpr
+}
+
+func (b queryBinary) sql(args []interface{}) (string, []interface{}) {
+	left, args := b.left.sql(args)
+	right, args := b.right.sql(args)
+	return "(" + left + " " + b.op + " " + right + ")", args
+}
+
+// queryToken is a lexical token of a boolean query: "(", ")", a keyword
+// (AND, OR, NOT) or a term with its text
+type queryToken struct {
+	kind string
+	text string
+}
+
+// Boolean query token kinds
+const (
+	queryTokenOpen  = "("
+	queryTokenClose = ")"
+	queryTokenAnd   = "AND"
+	queryTokenOr    = "OR"
+	queryTokenNot   = "NOT"
+	queryTokenTerm  = "term"
+)
+
+// lexQuery splits a boolean query into tokens. A term runs to the next space
+// or parenthesis; double quotes include spaces or parentheses in a term and
+// are dropped from its text, and make keywords plain terms. Errors wrap
+// invalid.
+func lexQuery(query string, invalid error) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(' || r == ')':
+			tokens = append(tokens, queryToken{kind: string(r)})
+			i++
+			continue
+		}
+
+		var word strings.Builder
+		quoted, hadQuotes := false, false
+		for ; i < len(runes); i++ {
+			r := runes[i]
+			if r == '"' {
+				quoted = !quoted
+				hadQuotes = true
+				continue
+			}
+			if !quoted && (unicode.IsSpace(r) || r == '(' || r == ')') {
+				break
+			}
+			word.WriteRune(r)
+		}
+		if quoted {
+			return nil, fmt.Errorf("%w: unterminated quote", invalid)
+		}
+
+		// A quoted "OR" is a term, not a keyword
+		text := word.String()
+		switch keyword := strings.ToUpper(text); {
+		case hadQuotes:
+		case keyword == queryTokenAnd, keyword == queryTokenOr, keyword == queryTokenNot:
+			tokens = append(tokens, queryToken{kind: keyword})
+			continue
+		}
+		tokens = append(tokens, queryToken{kind: queryTokenTerm, text: text})
+	}
+	return tokens, nil
+}
+
+// cutPrefixFold returns s without prefix, matched case-insensitively, and
+// whether s had the prefix
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// queryParser is a recursive descent parser over the grammar
+//
+//	or   = and { OR and }
+//	and  = not { [AND] not }
+//	not  = NOT not | atom
+//	atom = term | "(" or ")"
+//
+// where adjacent terms without a keyword are ANDed. Terms are turned into
+// expressions by parseTerm.
+type queryParser struct {
+	tokens    []queryToken
+	pos       int
+	terms     int
+	depth     int
+	parseTerm func(text string) (queryExpr, error)
+	invalid   error
+}
+
+// parseQuery parses a boolean query whose terms are parsed by parseTerm.
+// Syntax errors wrap invalid.
+func parseQuery(query string, parseTerm func(text string) (queryExpr, error), invalid error) (queryExpr, error) {
+	tokens, err := lexQuery(query, invalid)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty query", invalid)
+	}
+
+	p := &queryParser{tokens: tokens, parseTerm: parseTerm, invalid: invalid}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", invalid, p.tokens[p.pos].kind)
+	}
+	return expr, nil
+}
+
+func (p *queryParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos].kind
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == queryTokenOr {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = queryBinary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case queryTokenAnd:
+			p.pos++
+		case queryTokenNot, queryTokenOpen, queryTokenTerm:
+			// Implicit AND
+		default:
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = queryBinary{op: "AND", left: left, right: right}
+	}
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	if p.peek() != queryTokenNot {
+		return p.parseAtom()
+	}
+	p.pos++
+	expr, err := p.nested(p.parseNot)
+	if err != nil {
+		return nil, err
+	}
+	return queryNot{expr: expr}, nil
+}
+
+func (p *queryParser) parseAtom() (queryExpr, error) {
+	switch p.peek() {
+	case queryTokenTerm:
+		p.terms++
+		if p.terms > maxQueryTerms {
+			return nil, fmt.Errorf("%w: more than %d terms", p.invalid, maxQueryTerms)
+		}
+		term, err := p.parseTerm(p.tokens[p.pos].text)
+		if err != nil {
+			return nil, err
+		}
+		p.pos++
+		return term, nil
+	case queryTokenOpen:
+		p.pos++
+		expr, err := p.nested(p.parseOr)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != queryTokenClose {
+			return nil, fmt.Errorf("%w: missing )", p.invalid)
+		}
+		p.pos++
+		return expr, nil
+	case "":
+		return nil, fmt.Errorf("%w: unexpected end of query", p.invalid)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %q", p.invalid, p.peek())
+	}
+}
+
+// nested parses a sub-expression one level deeper, bounding the nesting
+func (p *queryParser) nested(parse func() (queryExpr, error)) (queryExpr, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxQueryDepth {
+		return nil, fmt.Errorf("%w: nested more than %d deep", p.invalid, maxQueryDepth)
+	}
+	return parse()
+}
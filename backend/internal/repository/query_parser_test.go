@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errTestQuery = errors.New("invalid test query")
+
+// testTerm renders a term as t($n) with its text as the arg
+type testTerm struct {
+	text string
+}
+
+func (t testTerm) sql(args []interface{}) (string, []interface{}) {
+	args = append(args, t.text)
+	return fmt.Sprintf("t($%d)", len(args)), args
+}
+
+func parseTestTerm(text string) (queryExpr, error) {
+	if text == "bad" {
+		return nil, fmt.Errorf("%w: bad term", errTestQuery)
+	}
+	return testTerm{text: text}, nil
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "single term",
+			query:    "a",
+			wantSQL:  "t($2)",
+			wantArgs: []interface{}{"user", "a"},
+		},
+		{
+			name:     "implicit AND",
+			query:    "a b c",
+			wantSQL:  "((t($2) AND t($3)) AND t($4))",
+			wantArgs: []interface{}{"user", "a", "b", "c"},
+		},
+		{
+			name:     "explicit AND in any case",
+			query:    "a and b",
+			wantSQL:  "(t($2) AND t($3))",
+			wantArgs: []interface{}{"user", "a", "b"},
+		},
+		{
+			name:     "AND binds tighter than OR",
+			query:    "a OR b c",
+			wantSQL:  "(t($2) OR (t($3) AND t($4)))",
+			wantArgs: []interface{}{"user", "a", "b", "c"},
+		},
+		{
+			name:     "OR is left associative",
+			query:    "a OR b OR c",
+			wantSQL:  "((t($2) OR t($3)) OR t($4))",
+			wantArgs: []interface{}{"user", "a", "b", "c"},
+		},
+		{
+			name:     "parentheses group OR",
+			query:    "a AND (b OR c)",
+			wantSQL:  "(t($2) AND (t($3) OR t($4)))",
+			wantArgs: []interface{}{"user", "a", "b", "c"},
+		},
+		{
+			name:     "NOT treats NULL as false",
+			query:    "NOT a",
+			wantSQL:  "NOT COALESCE(t($2), FALSE)",
+			wantArgs: []interface{}{"user", "a"},
+		},
+		{
+			name:     "NOT binds tighter than AND",
+			query:    "NOT a b",
+			wantSQL:  "(NOT COALESCE(t($2), FALSE) AND t($3))",
+			wantArgs: []interface{}{"user", "a", "b"},
+		},
+		{
+			name:     "NOT of a group",
+			query:    "a NOT (b OR c)",
+			wantSQL:  "(t($2) AND NOT COALESCE((t($3) OR t($4)), FALSE))",
+			wantArgs: []interface{}{"user", "a", "b", "c"},
+		},
+		{
+			name:     "quotes keep spaces and parentheses in a term",
+			query:    `a:"b (c) d" e`,
+			wantSQL:  "(t($2) AND t($3))",
+			wantArgs: []interface{}{"user", "a:b (c) d", "e"},
+		},
+		{
+			name:     "quoted keyword is a term",
+			query:    `"OR"`,
+			wantSQL:  "t($2)",
+			wantArgs: []interface{}{"user", "OR"},
+		},
+		{
+			name:     "parentheses need no spaces",
+			query:    "(a)(b)",
+			wantSQL:  "(t($2) AND t($3))",
+			wantArgs: []interface{}{"user", "a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseQuery(tt.query, parseTestTerm, errTestQuery)
+			if err != nil {
+				t.Fatalf("parseQuery(%q) error: %v", tt.query, err)
+			}
+			sql, args := expr.sql([]interface{}{"user"})
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{"empty", "   ", "empty query"},
+		{"unterminated quote", `a "b c`, "unterminated quote"},
+		{"missing close", "(a OR b", "missing )"},
+		{"unexpected close", "a)", `unexpected ")"`},
+		{"dangling OR", "a OR", "unexpected end of query"},
+		{"dangling NOT", "a NOT", "unexpected end of query"},
+		{"leading AND", "AND a", `unexpected "AND"`},
+		{"empty group", "()", `unexpected ")"`},
+		{"term error", "a bad", "bad term"},
+		{"too many terms", strings.Repeat("a ", maxQueryTerms+1), fmt.Sprintf("more than %d terms", maxQueryTerms)},
+		{"too deep", strings.Repeat("(", maxQueryDepth+1) + "a" + strings.Repeat(")", maxQueryDepth+1), fmt.Sprintf("nested more than %d deep", maxQueryDepth)},
+		{"too many NOTs", strings.Repeat("NOT ", maxQueryDepth+1) + "a", fmt.Sprintf("nested more than %d deep", maxQueryDepth)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseQuery(tt.query, parseTestTerm, errTestQuery)
+			if err == nil {
+				t.Fatalf("parseQuery(%q) succeeded, want error", tt.query)
+			}
+			if !errors.Is(err, errTestQuery) {
+				t.Errorf("error %v does not wrap %v", err, errTestQuery)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseQueryLimits(t *testing.T) {
+	terms := strings.Repeat("a ", maxQueryTerms)
+	if _, err := parseQuery(terms, parseTestTerm, errTestQuery); err != nil {
+		t.Errorf("%d terms: %v", maxQueryTerms, err)
+	}
+
+	nested := strings.Repeat("(", maxQueryDepth) + "a" + strings.Repeat(")", maxQueryDepth)
+	if _, err := parseQuery(nested, parseTestTerm, errTestQuery); err != nil {
+		t.Errorf("%d levels of nesting: %v", maxQueryDepth, err)
+	}
+}
+
+func TestParseTagQuery(t *testing.T) {
+	expr, err := parseTagQuery(`tag:GRE NOT tag:"#Phrasal Verbs"`)
+	if err != nil {
+		t.Fatalf("parseTagQuery error: %v", err)
+	}
+	sql, args := expr.sql([]interface{}{"user"})
+	if want := []interface{}{"user", "gre", "phrasal verbs"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %v, want %v", args, want)
+	}
+	if !strings.Contains(sql, "= $2") || !strings.Contains(sql, "AND NOT COALESCE(EXISTS") || !strings.Contains(sql, "= $3") {
+		t.Errorf("sql = %s", sql)
+	}
+
+	for _, query := range []string{"gre", "tag:", `tag:"#"`} {
+		if _, err := parseTagQuery(query); !errors.Is(err, ErrInvalidTagQuery) {
+			t.Errorf("parseTagQuery(%q) error = %v, want %v", query, err, ErrInvalidTagQuery)
+		}
+	}
+}
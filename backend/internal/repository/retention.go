@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GradedReview is a review of a graduated card with the card's state before
+// it, from which the scheduler's prediction for the review can be rebuilt
+type GradedReview struct {
+	StudyDate     time.Time
+	Scheduler     string
+	PrevInterval  int
+	PrevStability float64
+	// ElapsedDays is the time since the card's previous review
+	ElapsedDays float64
+	Passed      bool
+	IsLapse     bool
+}
+
+// GetGradedReviews returns the user's reviews since from of cards that had
+// graduated to the review state, oldest first. The state before each review
+// is taken from its undo snapshot, or from the card's previous log for
+// reviews recorded before snapshots were kept.
+func (r *StatsRepository) GetGradedReviews(ctx context.Context, userID string, from time.Time) ([]GradedReview, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH ordered AS (
+			SELECT
+				rl.reviewed_at,
+				rl.quality,
+				rl.is_lapse,
+				` + studyDateSQL("rl.reviewed_at", "$2", "$3") + ` AS study_date,
+				LAG(rl.scheduler) OVER w AS prev_scheduler,
+				COALESCE(rl.prev_card_state, LAG(rl.card_state) OVER w) AS prev_state,
+				COALESCE(rl.prev_interval, LAG(rl.interval) OVER w) AS prev_interval,
+				COALESCE(rl.prev_stability, LAG(rl.stability) OVER w) AS prev_stability,
+				COALESCE(rl.prev_last_reviewed_at, LAG(rl.reviewed_at) OVER w) AS prev_reviewed_at
+			FROM review_logs rl
+			JOIN review_cards rc ON rl.card_id = rc.id
+			WHERE rc.user_id = $1
+			WINDOW w AS (PARTITION BY rl.card_id ORDER BY rl.reviewed_at, rl.created_at)
+		)
+		SELECT
+			study_date,
+			COALESCE(prev_scheduler, 'sm2'),
+			COALESCE(prev_interval, 0),
+			COALESCE(prev_stability, 0),
+			COALESCE(GREATEST(EXTRACT(EPOCH FROM (reviewed_at - prev_reviewed_at)) / 86400.0, 0), 0)::float8,
+			quality >= 3,
+			is_lapse
+		FROM ordered
+		WHERE prev_state = 'review'
+		  AND reviewed_at >= $4
+		ORDER BY reviewed_at
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, boundary.Timezone, boundary.RolloverHour, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query graded reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []GradedReview
+	for rows.Next() {
+		var review GradedReview
+		err := rows.Scan(
+			&review.StudyDate,
+			&review.Scheduler,
+			&review.PrevInterval,
+			&review.PrevStability,
+			&review.ElapsedDays,
+			&review.Passed,
+			&review.IsLapse,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan graded review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating graded reviews: %w", err)
+	}
+
+	return reviews, nil
+}
+
+// EaseCount is the number of graduated cards at one easiness factor,
+// rounded to one decimal
+type EaseCount struct {
+	Ease  float64 `json:"ease"`
+	Cards int     `json:"cards"`
+}
+
+// GetEaseDistribution counts the user's unsuspended graduated cards by
+// easiness factor
+func (r *StatsRepository) GetEaseDistribution(ctx context.Context, userID string) ([]EaseCount, error) {
+	query := `
+		SELECT ROUND(rc.easiness_factor::numeric, 1)::float8 AS ease, COUNT(*)
+		FROM review_cards rc
+		JOIN user_words uw ON rc.user_word_id = uw.id
+		WHERE rc.user_id = $1
+		  AND rc.card_state = 'review'
+		  AND uw.suspended_at IS NULL
+		GROUP BY ease
+		ORDER BY ease
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ease distribution: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []EaseCount{}
+	for rows.Next() {
+		var count EaseCount
+		if err := rows.Scan(&count.Ease, &count.Cards); err != nil {
+			return nil, fmt.Errorf("failed to scan ease count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating ease distribution: %w", err)
+	}
+
+	return counts, nil
+}
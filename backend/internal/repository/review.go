@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // ReviewRepository handles review-related database operations
@@ -18,8 +19,10 @@ func NewReviewRepository(db *DB) *ReviewRepository {
 	return &ReviewRepository{db: db}
 }
 
-// DueWord represents a word that needs review with its details
+// DueWord represents a review card of a word with the word's details
 type DueWord struct {
+	CardID          uuid.UUID  `json:"card_id"`
+	CardType        string     `json:"card_type"`
 	UserWordID      uuid.UUID  `json:"user_word_id"`
 	WordID          uuid.UUID  `json:"word_id"`
 	Word            string     `json:"word"`
@@ -28,54 +31,133 @@ type DueWord struct {
 	EasinessFactor  float64    `json:"easiness_factor"`
 	Interval        int        `json:"interval"`
 	Repetitions     int        `json:"repetitions"`
+	Stability       float64    `json:"stability"`
+	Difficulty      float64    `json:"difficulty"`
+	CardState       string     `json:"card_state"`
+	LearningStep    int        `json:"learning_step"`
 	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
 	NextReviewAt    *time.Time `json:"next_review_at"`
+	Lapses          int        `json:"lapses"`
 	ContextSentence string     `json:"context_sentence"`
+	// Mnemonic and CustomDefinition are written by the user, typically for leeches
+	Mnemonic         string `json:"mnemonic"`
+	CustomDefinition string `json:"custom_definition"`
 }
 
-// GetDueWords retrieves words that are due for review, sorted by forgetting probability
+// LearnAheadLimit is how far ahead learning and relearning cards are returned as due,
+// so that a session does not stall while the next intra-day step is minutes away
+const LearnAheadLimit = 20 * time.Minute
+
+// GetDueWords retrieves previously studied cards that are due for review, sorted by forgetting probability
 // Higher forgetting probability = more urgent to review
-func (r *ReviewRepository) GetDueWords(ctx context.Context, userID string, limit int) ([]DueWord, error) {
+// Learning cards due within LearnAheadLimit are included so they can be shown in the current session.
+// Cards that have never been studied are left to GetNewWords, so that the daily new card limit applies to them.
+// Only cards of words matching filter are returned.
+func (r *ReviewRepository) GetDueWords(ctx context.Context, userID string, limit int, filter WordFilter) ([]DueWord, error) {
+	learnAheadCutoff := time.Now().Add(LearnAheadLimit)
+	where, args, err := filter.conditions([]interface{}{userID, limit, learnAheadCutoff})
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT 
-			uw.id as user_word_id,
-			w.id as word_id,
-			w.word,
-			w.phonetic,
-			w.definitions::text,
-			COALESCE(uw.easiness_factor, 2.5) as easiness_factor,
-			COALESCE(uw.interval, 0) as interval,
-			COALESCE(uw.repetitions, 0) as repetitions,
-			uw.last_reviewed_at,
-			uw.next_review_at,
-			COALESCE(uw.context_sentence, '') as context_sentence
-		FROM user_words uw
-		JOIN words w ON uw.word_id = w.id
-		WHERE uw.user_id = $1
-		  AND (uw.next_review_at IS NULL OR uw.next_review_at <= NOW())
+		SELECT ` + dueWordColumns + `
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.card_state <> 'new'
+		  AND (
+			rc.next_review_at <= NOW()
+			-- Learning cards coming due shortly are shown in the current session
+			OR (rc.card_state IN ('learning', 'relearning') AND rc.next_review_at <= $3)
+		  )
+		  AND ` + reviewableWordSQL + `
+		  AND ` + where + `
 		ORDER BY 
+			-- Learning cards that are due come first, learn-ahead cards last
+			CASE
+				WHEN rc.card_state IN ('learning', 'relearning') AND rc.next_review_at <= NOW() THEN 0
+				WHEN rc.card_state IN ('learning', 'relearning') THEN 2
+				ELSE 1
+			END ASC,
+			CASE WHEN rc.card_state IN ('learning', 'relearning') THEN rc.next_review_at END ASC,
 			-- Forgetting probability: time elapsed / expected interval
 			-- Higher value = more overdue = higher priority
 			CASE 
-				WHEN uw.last_reviewed_at IS NOT NULL AND uw.interval > 0 THEN
-					EXTRACT(EPOCH FROM (NOW() - uw.last_reviewed_at)) / (uw.interval * 86400.0)
-				ELSE 999999 -- New words (never reviewed) get highest priority
+				WHEN rc.last_reviewed_at IS NOT NULL AND rc.interval > 0 THEN
+					EXTRACT(EPOCH FROM (NOW() - rc.last_reviewed_at)) / (rc.interval * 86400.0)
+				ELSE 0
 			END DESC,
-			uw.easiness_factor ASC,  -- Harder words first
-			uw.repetitions ASC       -- Less practiced words first
+			rc.easiness_factor ASC,  -- Harder cards first
+			rc.repetitions ASC,      -- Less practiced cards first
+			` + cardTypeOrder + ` ASC
 		LIMIT $2
 	`
 
-	rows, err := r.db.Pool.Query(ctx, query, userID, limit)
+	rows, err := r.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query due words: %w", err)
 	}
+
+	dueWords, err := scanDueWords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return dueWords, nil
+}
+
+// dueWordColumns are the review_cards (rc), user_words (uw) and words (w)
+// columns scanned by scanDueWords
+const dueWordColumns = `
+	rc.id as card_id,
+	rc.card_type,
+	uw.id as user_word_id,
+	w.id as word_id,
+	w.word,
+	w.phonetic,
+	w.definitions::text,
+	rc.easiness_factor,
+	rc.interval,
+	rc.repetitions,
+	rc.stability,
+	rc.difficulty,
+	rc.card_state,
+	rc.learning_step,
+	rc.last_reviewed_at,
+	rc.next_review_at,
+	rc.lapses,
+	COALESCE(uw.context_sentence, '') as context_sentence,
+	COALESCE(uw.mnemonic, '') as mnemonic,
+	COALESCE(uw.custom_definition, '') as custom_definition
+`
+
+// dueWordFrom joins the tables read by dueWordColumns
+const dueWordFrom = `
+		FROM review_cards rc
+		JOIN user_words uw ON rc.user_word_id = uw.id
+		JOIN words w ON uw.word_id = w.id
+`
+
+// cardTypeOrder sorts a word's cards from the easiest direction to the hardest
+const cardTypeOrder = `
+	CASE rc.card_type
+		WHEN 'recognition' THEN 0
+		WHEN 'recall' THEN 1
+		WHEN 'spelling' THEN 2
+		ELSE 3
+	END
+`
+
+// scanDueWords reads rows selected with dueWordColumns
+func scanDueWords(rows pgx.Rows) ([]DueWord, error) {
 	defer rows.Close()
 
 	var dueWords []DueWord
 	for rows.Next() {
 		var word DueWord
 		err := rows.Scan(
+			&word.CardID,
+			&word.CardType,
 			&word.UserWordID,
 			&word.WordID,
 			&word.Word,
@@ -84,9 +166,16 @@ func (r *ReviewRepository) GetDueWords(ctx context.Context, userID string, limit
 			&word.EasinessFactor,
 			&word.Interval,
 			&word.Repetitions,
+			&word.Stability,
+			&word.Difficulty,
+			&word.CardState,
+			&word.LearningStep,
 			&word.LastReviewedAt,
 			&word.NextReviewAt,
+			&word.Lapses,
 			&word.ContextSentence,
+			&word.Mnemonic,
+			&word.CustomDefinition,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan due word: %w", err)
@@ -101,37 +190,217 @@ func (r *ReviewRepository) GetDueWords(ctx context.Context, userID string, limit
 	return dueWords, nil
 }
 
-// ReviewLog represents a review log entry
-type ReviewLog struct {
-	ID             uuid.UUID `json:"id"`
-	UserWordID     uuid.UUID `json:"user_word_id"`
-	Quality        int       `json:"quality"`
-	EasinessFactor float64   `json:"easiness_factor"`
-	Interval       int       `json:"interval"`
-	Repetitions    int       `json:"repetitions"`
-	NextReviewAt   time.Time `json:"next_review_at"`
-	ReviewedAt     time.Time `json:"reviewed_at"`
+// GetScheduledWords returns every card of a reviewable (neither suspended
+// nor buried) word the user has reviewed at least once, with its current
+// scheduling state
+func (r *ReviewRepository) GetScheduledWords(ctx context.Context, userID string) ([]DueWord, error) {
+	query := `
+		SELECT ` + dueWordColumns + `
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.last_reviewed_at IS NOT NULL
+		  AND ` + reviewableWordSQL + `
+		ORDER BY rc.next_review_at ASC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scheduled words: %w", err)
+	}
+
+	return scanDueWords(rows)
 }
 
-// CreateReviewLog records a review session in the review_logs table
-func (r *ReviewRepository) CreateReviewLog(ctx context.Context, log ReviewLog) error {
+// GetNewWords returns cards that have never been studied, oldest collected
+// word first and each word's easier directions before its harder ones. Only
+// cards of words matching filter are returned.
+func (r *ReviewRepository) GetNewWords(ctx context.Context, userID string, limit int, filter WordFilter) ([]DueWord, error) {
+	where, args, err := filter.conditions([]interface{}{userID, limit})
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + dueWordColumns + `
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.card_state = 'new'
+		  AND ` + reviewableWordSQL + `
+		  AND ` + where + `
+		ORDER BY uw.collected_at ASC, uw.id ASC, ` + cardTypeOrder + ` ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query new words: %w", err)
+	}
+
+	return scanDueWords(rows)
+}
+
+// GetReviewCard returns one of the user's cards with its scheduling state,
+// or nil if the user has no such card
+func (r *ReviewRepository) GetReviewCard(ctx context.Context, userID string, cardID uuid.UUID) (*DueWord, error) {
+	query := `
+		SELECT ` + dueWordColumns + `
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.id = $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review card: %w", err)
+	}
+
+	return firstDueWord(rows)
+}
+
+// GetWordCard returns the card of the given type of one of the user's words,
+// or nil if the word has no such card
+func (r *ReviewRepository) GetWordCard(ctx context.Context, userID string, userWordID uuid.UUID, cardType string) (*DueWord, error) {
 	query := `
-		INSERT INTO review_logs (
-			user_word_id, quality, easiness_factor, interval, 
-			repetitions, next_review_at, reviewed_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		SELECT ` + dueWordColumns + `
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.user_word_id = $2
+		  AND rc.card_type = $3
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	rows, err := r.db.Pool.Query(ctx, query, userID, userWordID, cardType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review card: %w", err)
+	}
+
+	return firstDueWord(rows)
+}
+
+// firstDueWord scans rows selected with dueWordColumns and returns the first, if any
+func firstDueWord(rows pgx.Rows) (*DueWord, error) {
+	words, err := scanDueWords(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	return &words[0], nil
+}
+
+// ReviewLog represents a review log entry
+type ReviewLog struct {
+	ID             uuid.UUID  `json:"id"`
+	CardID         uuid.UUID  `json:"card_id"`
+	UserWordID     uuid.UUID  `json:"user_word_id"`
+	Quality        int        `json:"quality"`
+	EasinessFactor float64    `json:"easiness_factor"`
+	Interval       int        `json:"interval"`
+	Repetitions    int        `json:"repetitions"`
+	Stability      float64    `json:"stability"`
+	Difficulty     float64    `json:"difficulty"`
+	Scheduler      string     `json:"scheduler"`
+	CardState      string     `json:"card_state"`
+	SessionID      *uuid.UUID `json:"session_id,omitempty"`
+	NextReviewAt   time.Time  `json:"next_review_at"`
+	ReviewedAt     time.Time  `json:"reviewed_at"`
+	// Previous is the card's state before the review, used to undo it
+	Previous  *CardSnapshot `json:"previous,omitempty"`
+	XPAwarded int           `json:"xp_awarded"`
+	Requeued  bool          `json:"requeued"`
+	// ResponseTimeMs is nil when the client did not report it
+	ResponseTimeMs *int   `json:"response_time_ms"`
+	CardMode       string `json:"card_mode"`
+	ClientType     string `json:"client_type,omitempty"`
+	// IsLapse marks a failed review of a card that had graduated
+	IsLapse bool `json:"is_lapse"`
+	// LeechSuspended is set when the review made the word a leech and suspended it
+	LeechSuspended bool `json:"leech_suspended"`
+}
+
+// CardSnapshot is the scheduling state of a review card at one point in time
+type CardSnapshot struct {
+	EasinessFactor float64    `json:"easiness_factor"`
+	Interval       int        `json:"interval"`
+	Repetitions    int        `json:"repetitions"`
+	Stability      float64    `json:"stability"`
+	Difficulty     float64    `json:"difficulty"`
+	CardState      string     `json:"card_state"`
+	LearningStep   int        `json:"learning_step"`
+	LastReviewedAt *time.Time `json:"last_reviewed_at"`
+	NextReviewAt   *time.Time `json:"next_review_at"`
+}
+
+// Snapshot returns the scheduling state of a due card
+func (w DueWord) Snapshot() CardSnapshot {
+	return CardSnapshot{
+		EasinessFactor: w.EasinessFactor,
+		Interval:       w.Interval,
+		Repetitions:    w.Repetitions,
+		Stability:      w.Stability,
+		Difficulty:     w.Difficulty,
+		CardState:      w.CardState,
+		LearningStep:   w.LearningStep,
+		LastReviewedAt: w.LastReviewedAt,
+		NextReviewAt:   w.NextReviewAt,
+	}
+}
+
+const createReviewLogQuery = `
+	INSERT INTO review_logs (
+		user_word_id, quality, easiness_factor, interval, 
+		repetitions, stability, difficulty, scheduler, card_state,
+		session_id, next_review_at, reviewed_at,
+		prev_easiness_factor, prev_interval, prev_repetitions, prev_stability, prev_difficulty,
+		prev_card_state, prev_learning_step, prev_last_reviewed_at, prev_next_review_at,
+		xp_awarded, requeued, response_time_ms, card_mode, client_type, card_id,
+		is_lapse, leech_suspended
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23,
+		$24, COALESCE(NULLIF($25, ''), 'recognition'), NULLIF($26, ''), $27, $28, $29)
+`
+
+// reviewLogArgs returns the createReviewLogQuery arguments for a review log
+func reviewLogArgs(log ReviewLog) []interface{} {
+	args := []interface{}{
 		log.UserWordID,
 		log.Quality,
 		log.EasinessFactor,
 		log.Interval,
 		log.Repetitions,
+		log.Stability,
+		log.Difficulty,
+		log.Scheduler,
+		log.CardState,
+		log.SessionID,
 		log.NextReviewAt,
 		log.ReviewedAt,
-	)
+	}
+
+	// Logs without a snapshot store NULLs so undo falls back to the previous log
+	if prev := log.Previous; prev != nil {
+		args = append(args,
+			prev.EasinessFactor,
+			prev.Interval,
+			prev.Repetitions,
+			prev.Stability,
+			prev.Difficulty,
+			prev.CardState,
+			prev.LearningStep,
+			prev.LastReviewedAt,
+			prev.NextReviewAt,
+		)
+	} else {
+		args = append(args, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	}
 
+	return append(args, log.XPAwarded, log.Requeued, log.ResponseTimeMs, log.CardMode, log.ClientType, log.CardID,
+		log.IsLapse, log.LeechSuspended)
+}
+
+// CreateReviewLog records a review session in the review_logs table
+func (r *ReviewRepository) CreateReviewLog(ctx context.Context, log ReviewLog) error {
+	_, err := r.db.Pool.Exec(ctx, createReviewLogQuery, reviewLogArgs(log)...)
 	if err != nil {
 		return fmt.Errorf("failed to create review log: %w", err)
 	}
@@ -139,41 +408,380 @@ func (r *ReviewRepository) CreateReviewLog(ctx context.Context, log ReviewLog) e
 	return nil
 }
 
-// UpdateUserWordSM2 updates the SM-2 parameters for a user word
-func (r *ReviewRepository) UpdateUserWordSM2(
-	ctx context.Context,
-	userWordID uuid.UUID,
-	easinessFactor float64,
-	interval int,
-	repetitions int,
-	nextReviewAt time.Time,
-) error {
+// CardSchedule holds the scheduling state written back to a card after a review
+type CardSchedule struct {
+	EasinessFactor float64
+	Interval       int
+	Repetitions    int
+	Stability      float64
+	Difficulty     float64
+	CardState      string
+	LearningStep   int
+	LastReviewedAt time.Time
+	NextReviewAt   time.Time
+	Lapses         int
+}
+
+const updateCardScheduleQuery = `
+	UPDATE review_cards
+	SET 
+		easiness_factor = $2,
+		interval = $3,
+		repetitions = $4,
+		stability = $5,
+		difficulty = $6,
+		card_state = $7,
+		learning_step = $8,
+		last_reviewed_at = $9,
+		next_review_at = $10,
+		lapses = $11,
+		updated_at = NOW()
+	WHERE id = $1
+	RETURNING user_word_id
+`
+
+// scheduleArgs returns the updateCardScheduleQuery arguments for a card
+func scheduleArgs(cardID uuid.UUID, schedule CardSchedule) []interface{} {
+	return []interface{}{
+		cardID,
+		schedule.EasinessFactor,
+		schedule.Interval,
+		schedule.Repetitions,
+		schedule.Stability,
+		schedule.Difficulty,
+		schedule.CardState,
+		schedule.LearningStep,
+		schedule.LastReviewedAt,
+		schedule.NextReviewAt,
+		schedule.Lapses,
+	}
+}
+
+// syncUserWordQuery copies a word's card states onto its user_words row: the
+// recognition card's scheduling state, the latest review of any card, the
+// earliest upcoming review of any card and the lapses of all cards
+const syncUserWordQuery = `
+	UPDATE user_words uw
+	SET
+		easiness_factor = rc.easiness_factor,
+		interval = rc.interval,
+		repetitions = rc.repetitions,
+		stability = rc.stability,
+		difficulty = rc.difficulty,
+		card_state = rc.card_state,
+		learning_step = rc.learning_step,
+		last_reviewed_at = agg.last_reviewed_at,
+		next_review_at = agg.next_review_at,
+		lapses = agg.lapses,
+		updated_at = NOW()
+	FROM review_cards rc,
+		(
+			SELECT MAX(last_reviewed_at) AS last_reviewed_at, MIN(next_review_at) AS next_review_at,
+				SUM(lapses) AS lapses
+			FROM review_cards
+			WHERE user_word_id = $1
+		) agg
+	WHERE uw.id = $1
+	  AND rc.user_word_id = uw.id
+	  AND rc.card_type = 'recognition'
+`
+
+// updateCardSchedule writes a card's scheduling state within tx and keeps
+// its word's summary in step. It returns the card's user word ID.
+func updateCardSchedule(ctx context.Context, tx pgx.Tx, cardID uuid.UUID, schedule CardSchedule) (uuid.UUID, error) {
+	var userWordID uuid.UUID
+	err := tx.QueryRow(ctx, updateCardScheduleQuery, scheduleArgs(cardID, schedule)...).Scan(&userWordID)
+	if err == pgx.ErrNoRows {
+		return uuid.Nil, fmt.Errorf("review card not found")
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update card schedule: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, syncUserWordQuery, userWordID); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update user word: %w", err)
+	}
+
+	return userWordID, nil
+}
+
+// UpdateCardSchedule updates the scheduling state (SM-2 and FSRS) of a card
+func (r *ReviewRepository) UpdateCardSchedule(ctx context.Context, cardID uuid.UUID, schedule CardSchedule) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := updateCardSchedule(ctx, tx, cardID, schedule); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReviewSubmission is everything written when a card is answered
+type ReviewSubmission struct {
+	UserID   string
+	Log      ReviewLog
+	Schedule CardSchedule
+	// Session, when set, is written with its progress already advanced
+	// from SessionPosition
+	Session         *ReviewSession
+	SessionPosition int
+}
+
+// RecordedReview reports what an answer changed beyond the card's schedule
+type RecordedReview struct {
+	// BecameLeech is set when the answer took a card to the leech threshold
+	BecameLeech bool
+	// Suspended is set when the word was suspended as a leech
+	Suspended bool
+}
+
+// RecordReview stores an answer in one transaction: the card's new scheduling
+// state, leech detection for lapses, the review log, the log's XP added to
+// the profile and the session progress
+func (r *ReviewRepository) RecordReview(ctx context.Context, sub ReviewSubmission) (*RecordedReview, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	userWordID, err := updateCardSchedule(ctx, tx, sub.Log.CardID, sub.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := &RecordedReview{}
+	if sub.Log.IsLapse {
+		recorded.BecameLeech, recorded.Suspended, err = markLeech(ctx, tx, userWordID, sub.Log.ReviewedAt)
+		if err != nil {
+			return nil, err
+		}
+		sub.Log.LeechSuspended = recorded.Suspended
+	}
+
+	if _, err := tx.Exec(ctx, createReviewLogQuery, reviewLogArgs(sub.Log)...); err != nil {
+		return nil, fmt.Errorf("failed to create review log: %w", err)
+	}
+
+	if sub.Log.XPAwarded > 0 {
+		xpQuery := `
+			UPDATE profiles
+			SET xp = xp + $2, updated_at = NOW()
+			WHERE user_id = $1
+		`
+		if _, err := tx.Exec(ctx, xpQuery, sub.UserID, sub.Log.XPAwarded); err != nil {
+			return nil, fmt.Errorf("failed to award xp: %w", err)
+		}
+	}
+
+	if sub.Session != nil {
+		if err := updateSessionProgress(ctx, tx, sub.Session, sub.SessionPosition); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// StudyCounts is how much a user has studied in their current study day
+type StudyCounts struct {
+	// Reviewed counts distinct previously studied cards reviewed today
+	Reviewed int `json:"reviewed"`
+	// Introduced counts cards studied for the first time today
+	Introduced int `json:"introduced"`
+}
+
+// GetTodayStudyCounts counts the cards the user has reviewed and introduced
+// since their current study day started
+func (r *ReviewRepository) GetTodayStudyCounts(ctx context.Context, userID string) (*StudyCounts, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	dayStart, _ := boundary.Today(time.Now())
+
+	query := `
+		WITH studied AS (
+			SELECT rl.card_id, MIN(rl.reviewed_at) AS first_reviewed_at
+			FROM review_logs rl
+			JOIN user_words uw ON rl.user_word_id = uw.id
+			WHERE uw.user_id = $1
+			GROUP BY rl.card_id
+			HAVING MAX(rl.reviewed_at) >= $2
+		)
+		SELECT
+			COUNT(*) FILTER (WHERE first_reviewed_at < $2),
+			COUNT(*) FILTER (WHERE first_reviewed_at >= $2)
+		FROM studied
+	`
+
+	counts := &StudyCounts{}
+	err = r.db.Pool.QueryRow(ctx, query, userID, dayStart).Scan(&counts.Reviewed, &counts.Introduced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get today study counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetDueDistribution counts the user's cards of reviewable words coming due
+// on each of the next days, where day 0 is the 24 hours starting at from
+func (r *ReviewRepository) GetDueDistribution(ctx context.Context, userID string, from time.Time, days int) ([]int, error) {
+	query := `
+		SELECT FLOOR(EXTRACT(EPOCH FROM (rc.next_review_at - $2)) / 86400)::int AS day, COUNT(*)
+		FROM review_cards rc
+		JOIN user_words uw ON rc.user_word_id = uw.id
+		WHERE rc.user_id = $1
+		  AND rc.next_review_at >= $2
+		  AND rc.next_review_at < $2 + make_interval(days => $3)
+		  AND ` + reviewableWordSQL + `
+		GROUP BY day
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, from, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due distribution: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make([]int, days)
+	for rows.Next() {
+		var day, count int
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan due distribution: %w", err)
+		}
+		if day >= 0 && day < days {
+			counts[day] = count
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating due distribution: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SchedulerSettings holds the scheduling preferences stored on a user's profile
+type SchedulerSettings struct {
+	Scheduler        string
+	LearningSteps    []int
+	RelearningSteps  []int
+	DesiredRetention float64
+	SchedulerParams  []byte // JSON, nil until parameters have been fitted
+}
+
+// GetSchedulerSettings returns the scheduling preferences selected by the user
+func (r *ReviewRepository) GetSchedulerSettings(ctx context.Context, userID string) (*SchedulerSettings, error) {
+	query := `
+		SELECT scheduler, learning_steps, relearning_steps, desired_retention, scheduler_params
+		FROM profiles
+		WHERE user_id = $1
+	`
+
+	settings := &SchedulerSettings{}
+	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(
+		&settings.Scheduler,
+		&settings.LearningSteps,
+		&settings.RelearningSteps,
+		&settings.DesiredRetention,
+		&settings.SchedulerParams,
+	)
+	if err == pgx.ErrNoRows {
+		// No profile yet, use the column defaults
+		return &SchedulerSettings{
+			Scheduler:        "sm2",
+			LearningSteps:    []int{1, 10, 60},
+			RelearningSteps:  []int{10},
+			DesiredRetention: 0.9,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// Orders in which new cards are placed among due reviews
+const (
+	NewCardOrderMixed        = "mixed"         // spread evenly through the reviews
+	NewCardOrderNewFirst     = "new_first"     // before the reviews
+	NewCardOrderReviewsFirst = "reviews_first" // after the reviews
+)
+
+// StudyLimits holds the daily study limits stored on a user's profile
+type StudyLimits struct {
+	NewCardsPerDay int
+	ReviewsPerDay  int
+	// NewCardRatio is the share of a due list kept for new cards when
+	// reviews alone would fill it
+	NewCardRatio float64
+	NewCardOrder string
+}
+
+// DefaultStudyLimits are the profiles column defaults
+var DefaultStudyLimits = StudyLimits{
+	NewCardsPerDay: 20,
+	ReviewsPerDay:  200,
+	NewCardRatio:   0.25,
+	NewCardOrder:   NewCardOrderMixed,
+}
+
+// GetStudyLimits returns the user's daily new card and review limits
+func (r *ReviewRepository) GetStudyLimits(ctx context.Context, userID string) (*StudyLimits, error) {
 	query := `
-		UPDATE user_words
-		SET 
-			easiness_factor = $2,
-			interval = $3,
-			repetitions = $4,
-			last_reviewed_at = NOW(),
-			next_review_at = $5,
-			updated_at = NOW()
-		WHERE id = $1
+		SELECT new_cards_per_day, reviews_per_day, new_card_ratio::float8, new_card_order
+		FROM profiles
+		WHERE user_id = $1
 	`
 
-	result, err := r.db.Pool.Exec(ctx, query,
-		userWordID,
-		easinessFactor,
-		interval,
-		repetitions,
-		nextReviewAt,
+	limits := &StudyLimits{}
+	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(
+		&limits.NewCardsPerDay,
+		&limits.ReviewsPerDay,
+		&limits.NewCardRatio,
+		&limits.NewCardOrder,
 	)
+	if err == pgx.ErrNoRows {
+		// No profile yet, use the column defaults
+		defaults := DefaultStudyLimits
+		return &defaults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get study limits: %w", err)
+	}
+
+	return limits, nil
+}
+
+// UpdateSchedulerParams stores the user's fitted scheduler parameters (JSON)
+func (r *ReviewRepository) UpdateSchedulerParams(ctx context.Context, userID string, params []byte) error {
+	query := `
+		UPDATE profiles
+		SET scheduler_params = $2, updated_at = NOW()
+		WHERE user_id = $1
+	`
 
+	result, err := r.db.Pool.Exec(ctx, query, userID, params)
 	if err != nil {
-		return fmt.Errorf("failed to update user word SM-2: %w", err)
+		return fmt.Errorf("failed to update scheduler params: %w", err)
 	}
 
 	if result.RowsAffected() == 0 {
-		return fmt.Errorf("user word not found")
+		return fmt.Errorf("profile not found")
 	}
 
 	return nil
@@ -187,31 +795,41 @@ type TodayStats struct {
 	MasteredToday int `json:"mastered_today"`
 }
 
-// GetTodayStats retrieves today's review statistics for a user
+// GetTodayStats retrieves review statistics for the user's current study day,
+// which starts at their rollover hour in their time zone
 func (r *ReviewRepository) GetTodayStats(ctx context.Context, userID string) (*TodayStats, error) {
 	stats := &TodayStats{}
 
-	// Get total due words count
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	dayStart, dayEnd := boundary.Today(time.Now())
+
+	// Get total due cards count
 	dueQuery := `
 		SELECT COUNT(*)
-		FROM user_words
-		WHERE user_id = $1
-		  AND (next_review_at IS NULL OR next_review_at <= NOW())
+		FROM review_cards rc
+		JOIN user_words uw ON rc.user_word_id = uw.id
+		WHERE rc.user_id = $1
+		  AND (rc.next_review_at IS NULL OR rc.next_review_at < $2)
+		  AND uw.suspended_at IS NULL
+		  AND (uw.buried_until IS NULL OR uw.buried_until < $2)
 	`
-	err := r.db.Pool.QueryRow(ctx, dueQuery, userID).Scan(&stats.TotalDue)
+	err = r.db.Pool.QueryRow(ctx, dueQuery, userID, dayEnd).Scan(&stats.TotalDue)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get due count: %w", err)
 	}
 
 	// Get reviewed count today
 	reviewedQuery := `
-		SELECT COUNT(DISTINCT rl.user_word_id)
+		SELECT COUNT(DISTINCT rl.card_id)
 		FROM review_logs rl
 		JOIN user_words uw ON rl.user_word_id = uw.id
 		WHERE uw.user_id = $1
-		  AND rl.reviewed_at >= CURRENT_DATE
+		  AND rl.reviewed_at >= $2
 	`
-	err = r.db.Pool.QueryRow(ctx, reviewedQuery, userID).Scan(&stats.Reviewed)
+	err = r.db.Pool.QueryRow(ctx, reviewedQuery, userID, dayStart).Scan(&stats.Reviewed)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get reviewed count: %w", err)
 	}
@@ -221,9 +839,9 @@ func (r *ReviewRepository) GetTodayStats(ctx context.Context, userID string) (*T
 		SELECT COUNT(*)
 		FROM user_words
 		WHERE user_id = $1
-		  AND collected_at >= CURRENT_DATE
+		  AND collected_at >= $2
 	`
-	err = r.db.Pool.QueryRow(ctx, newWordsQuery, userID).Scan(&stats.NewWords)
+	err = r.db.Pool.QueryRow(ctx, newWordsQuery, userID, dayStart).Scan(&stats.NewWords)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get new words count: %w", err)
 	}
@@ -235,9 +853,9 @@ func (r *ReviewRepository) GetTodayStats(ctx context.Context, userID string) (*T
 		WHERE user_id = $1
 		  AND repetitions >= 5
 		  AND is_mastered = true
-		  AND updated_at >= CURRENT_DATE
+		  AND updated_at >= $2
 	`
-	err = r.db.Pool.QueryRow(ctx, masteredQuery, userID).Scan(&stats.MasteredToday)
+	err = r.db.Pool.QueryRow(ctx, masteredQuery, userID, dayStart).Scan(&stats.MasteredToday)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get mastered count: %w", err)
 	}
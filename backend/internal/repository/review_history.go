@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewEvent is a single answer recorded in review_logs
+type ReviewEvent struct {
+	Quality        int       `json:"quality"`
+	ReviewedAt     time.Time `json:"reviewed_at"`
+	ResponseTimeMs *int      `json:"response_time_ms"`
+	CardMode       string    `json:"card_mode"`
+}
+
+// CardHistory is the review history of one review card, oldest review first
+type CardHistory struct {
+	CardID       uuid.UUID     `json:"card_id"`
+	CardType     string        `json:"card_type"`
+	UserWordID   uuid.UUID     `json:"user_word_id"`
+	NextReviewAt *time.Time    `json:"next_review_at"`
+	Reviews      []ReviewEvent `json:"reviews"`
+}
+
+// ListReviewedUsers returns the IDs of all users that have at least one review log
+func (r *ReviewRepository) ListReviewedUsers(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT DISTINCT uw.user_id
+		FROM user_words uw
+		JOIN review_logs rl ON rl.user_word_id = uw.id
+		ORDER BY uw.user_id
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviewed users: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reviewed users: %w", err)
+	}
+
+	return userIDs, nil
+}
+
+// GetReviewHistory returns the review history of every reviewed card of a user,
+// with each card's reviews in the order they were made
+func (r *ReviewRepository) GetReviewHistory(ctx context.Context, userID string) ([]CardHistory, error) {
+	query := `
+		SELECT rc.id, rc.card_type, rc.user_word_id, rc.next_review_at,
+			rl.quality, rl.reviewed_at, rl.response_time_ms, rl.card_mode
+		FROM review_logs rl
+		JOIN review_cards rc ON rl.card_id = rc.id
+		WHERE rc.user_id = $1
+		ORDER BY rc.id, rl.reviewed_at ASC, rl.created_at ASC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []CardHistory
+	for rows.Next() {
+		var (
+			entry CardHistory
+			event ReviewEvent
+		)
+		if err := rows.Scan(&entry.CardID, &entry.CardType, &entry.UserWordID, &entry.NextReviewAt,
+			&event.Quality, &event.ReviewedAt, &event.ResponseTimeMs, &event.CardMode); err != nil {
+			return nil, fmt.Errorf("failed to scan review history: %w", err)
+		}
+
+		// Rows are grouped by card, so a new ID starts a new card
+		if len(history) == 0 || history[len(history)-1].CardID != entry.CardID {
+			history = append(history, entry)
+		}
+		card := &history[len(history)-1]
+		card.Reviews = append(card.Reviews, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review history: %w", err)
+	}
+
+	return history, nil
+}
+
+// UpdateCardSchedules rewrites the scheduling state of many cards in one transaction
+func (r *ReviewRepository) UpdateCardSchedules(ctx context.Context, schedules map[uuid.UUID]CardSchedule) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for cardID, schedule := range schedules {
+		if _, err := updateCardSchedule(ctx, tx, cardID, schedule); err != nil {
+			return fmt.Errorf("failed to update card %s: %w", cardID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReviewLogEntry is one answer in a user's review history
+type ReviewLogEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	UserWordID     uuid.UUID  `json:"user_word_id"`
+	CardID         *uuid.UUID `json:"card_id"`
+	CardType       string     `json:"card_type"`
+	Word           string     `json:"word"`
+	Quality        int        `json:"quality"`
+	CardMode       string     `json:"card_mode"`
+	CardState      string     `json:"card_state"`
+	Interval       int        `json:"interval"`
+	ResponseTimeMs *int       `json:"response_time_ms"`
+	NextReviewAt   *time.Time `json:"next_review_at"`
+	SessionID      *uuid.UUID `json:"session_id"`
+	ReviewedAt     time.Time  `json:"reviewed_at"`
+}
+
+// ReviewHistoryQuery selects one page of a user's review history, newest
+// first, optionally of one word or one session
+type ReviewHistoryQuery struct {
+	UserWordID *uuid.UUID
+	SessionID  *uuid.UUID
+	PageRequest
+}
+
+// reviewHistoryOrder lists reviews newest first
+var reviewHistoryOrder = keyset{
+	name:     "reviewed_at",
+	sort:     keysetSort{expr: "rl.reviewed_at", cast: "timestamptz"},
+	idColumn: "rl.id",
+	desc:     true,
+}
+
+// Validate checks the query's cursor, defaulting the page size
+func (q *ReviewHistoryQuery) Validate() error {
+	q.normalize()
+	if q.Cursor != "" {
+		if _, err := reviewHistoryOrder.decode(q.Cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReviewHistoryPage is one page of a user's review history
+type ReviewHistoryPage struct {
+	Reviews []ReviewLogEntry `json:"reviews"`
+	// NextCursor continues the history after this page; empty on the last page
+	NextCursor string `json:"next_cursor"`
+	// Total is the number of reviews matching the query, if requested
+	Total *int `json:"total,omitempty"`
+}
+
+// ListReviewHistory returns one page of the user's reviews, newest first,
+// and their total number if requested. The query must have been validated.
+func (r *ReviewRepository) ListReviewHistory(ctx context.Context, userID string, q ReviewHistoryQuery) (*ReviewHistoryPage, error) {
+	where := "uw.user_id = $1"
+	args := []interface{}{userID}
+	if q.UserWordID != nil {
+		args = append(args, *q.UserWordID)
+		where += fmt.Sprintf(" AND rl.user_word_id = $%d", len(args))
+	}
+	if q.SessionID != nil {
+		args = append(args, *q.SessionID)
+		where += fmt.Sprintf(" AND rl.session_id = $%d", len(args))
+	}
+
+	page := &ReviewHistoryPage{Reviews: []ReviewLogEntry{}}
+	if q.WithTotal {
+		var total int
+		countQuery := `
+			SELECT COUNT(*)
+			FROM review_logs rl
+			JOIN user_words uw ON rl.user_word_id = uw.id
+			WHERE ` + where
+		if err := r.db.Pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count reviews: %w", err)
+		}
+		page.Total = &total
+	}
+
+	after, args, err := reviewHistoryOrder.after(q.Cursor, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra review to tell whether there is a next page
+	args = append(args, q.Limit+1)
+	query := `
+		SELECT rl.id, rl.user_word_id, rl.card_id, COALESCE(rc.card_type, 'recognition'), w.word,
+			rl.quality, rl.card_mode, rl.card_state, COALESCE(rl.interval, 0), rl.response_time_ms,
+			rl.next_review_at, rl.session_id, rl.reviewed_at, ` + reviewHistoryOrder.keyColumn() + `
+		FROM review_logs rl
+		JOIN user_words uw ON rl.user_word_id = uw.id
+		JOIN words w ON uw.word_id = w.id
+		LEFT JOIN review_cards rc ON rl.card_id = rc.id
+		WHERE ` + where + `
+		  AND ` + after + `
+		ORDER BY ` + reviewHistoryOrder.orderBy() + `
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query review history: %w", err)
+	}
+	defer rows.Close()
+
+	var lastKey string
+	for rows.Next() {
+		var (
+			entry ReviewLogEntry
+			key   string
+		)
+		if err := rows.Scan(&entry.ID, &entry.UserWordID, &entry.CardID, &entry.CardType, &entry.Word,
+			&entry.Quality, &entry.CardMode, &entry.CardState, &entry.Interval, &entry.ResponseTimeMs,
+			&entry.NextReviewAt, &entry.SessionID, &entry.ReviewedAt, &key); err != nil {
+			return nil, fmt.Errorf("failed to scan review history: %w", err)
+		}
+
+		// The extra review only tells that the page ends at the previous one
+		if len(page.Reviews) == q.Limit {
+			page.NextCursor = reviewHistoryOrder.cursor(lastKey, page.Reviews[len(page.Reviews)-1].ID)
+			break
+		}
+		page.Reviews = append(page.Reviews, entry)
+		lastKey = key
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating review history: %w", err)
+	}
+
+	return page, nil
+}
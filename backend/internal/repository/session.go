@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Review session statuses
+const (
+	SessionStatusActive    = "active"
+	SessionStatusCompleted = "completed"
+)
+
+// ErrSessionChanged is returned when a session's progress was updated by
+// another request since it was read
+var ErrSessionChanged = errors.New("review session was changed concurrently")
+
+// ReviewSession is a queue of cards answered one at a time
+type ReviewSession struct {
+	ID             uuid.UUID   `json:"id"`
+	UserID         string      `json:"user_id"`
+	Status         string      `json:"status"`
+	CardIDs        []uuid.UUID `json:"card_ids"`
+	Position       int         `json:"position"`
+	NewCards       int         `json:"new_cards"`
+	Answered       int         `json:"answered"`
+	Correct        int         `json:"correct"`
+	XPEarned       int         `json:"xp_earned"`
+	StartedAt      time.Time   `json:"started_at"`
+	LastAnsweredAt *time.Time  `json:"last_answered_at"`
+	CompletedAt    *time.Time  `json:"completed_at"`
+}
+
+// sessionColumns lists the review_sessions columns scanned by scanSession, in order
+const sessionColumns = `id, user_id, status, card_ids, position, new_cards, answered,
+		correct, xp_earned, started_at, last_answered_at, completed_at`
+
+// scanSession scans a review_sessions row selected with sessionColumns
+func scanSession(row pgx.Row) (*ReviewSession, error) {
+	var session ReviewSession
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Status,
+		&session.CardIDs,
+		&session.Position,
+		&session.NewCards,
+		&session.Answered,
+		&session.Correct,
+		&session.XPEarned,
+		&session.StartedAt,
+		&session.LastAnsweredAt,
+		&session.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CreateReviewSession starts a session over the given queue of review cards
+func (r *ReviewRepository) CreateReviewSession(ctx context.Context, userID string, cardIDs []uuid.UUID, newCards int, startedAt time.Time) (*ReviewSession, error) {
+	status := SessionStatusActive
+	var completedAt *time.Time
+	if len(cardIDs) == 0 {
+		// Nothing to review: the session is over before it starts
+		status = SessionStatusCompleted
+		completedAt = &startedAt
+	}
+
+	query := `
+		INSERT INTO review_sessions (user_id, status, card_ids, new_cards, started_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + sessionColumns + `
+	`
+
+	session, err := scanSession(r.db.Pool.QueryRow(ctx, query, userID, status, cardIDs, newCards, startedAt, completedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create review session: %w", err)
+	}
+
+	return session, nil
+}
+
+// GetReviewSession returns one of the user's sessions, or nil if it does not exist
+func (r *ReviewRepository) GetReviewSession(ctx context.Context, userID string, sessionID uuid.UUID) (*ReviewSession, error) {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM review_sessions
+		WHERE id = $1 AND user_id = $2
+	`
+
+	session, err := scanSession(r.db.Pool.QueryRow(ctx, query, sessionID, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review session: %w", err)
+	}
+
+	return session, nil
+}
+
+// updateSessionProgress writes a session's progress within tx, provided the
+// session is still at fromPosition
+func updateSessionProgress(ctx context.Context, tx pgx.Tx, session *ReviewSession, fromPosition int) error {
+	query := `
+		UPDATE review_sessions
+		SET
+			status = $3,
+			card_ids = $4,
+			position = $5,
+			answered = $6,
+			correct = $7,
+			xp_earned = $8,
+			last_answered_at = $9,
+			completed_at = $10,
+			updated_at = NOW()
+		WHERE id = $1 AND position = $2
+	`
+
+	result, err := tx.Exec(ctx, query,
+		session.ID,
+		fromPosition,
+		session.Status,
+		session.CardIDs,
+		session.Position,
+		session.Answered,
+		session.Correct,
+		session.XPEarned,
+		session.LastAnsweredAt,
+		session.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update review session: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrSessionChanged
+	}
+
+	return nil
+}
+
+// GetSessionCards returns the cards among cardIDs that can still be reviewed,
+// by ID. Cards whose word was deleted, suspended or buried are left out.
+func (r *ReviewRepository) GetSessionCards(ctx context.Context, userID string, cardIDs []uuid.UUID) (map[uuid.UUID]DueWord, error) {
+	query := `
+		SELECT ` + dueWordColumns + `
+		` + dueWordFrom + `
+		WHERE rc.user_id = $1
+		  AND rc.id = ANY($2)
+		  AND ` + reviewableWordSQL + `
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, cardIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session cards: %w", err)
+	}
+
+	words, err := scanDueWords(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	cards := make(map[uuid.UUID]DueWord, len(words))
+	for _, word := range words {
+		cards[word.CardID] = word
+	}
+	return cards, nil
+}
+
+// UpdateSessionQueue writes a session's queue and position, provided the
+// session is still at fromPosition
+func (r *ReviewRepository) UpdateSessionQueue(ctx context.Context, session *ReviewSession, fromPosition int) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := updateSessionProgress(ctx, tx, session, fromPosition); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type StatsRepository struct {
@@ -14,130 +17,29 @@ func NewStatsRepository(db *DB) *StatsRepository {
 	return &StatsRepository{db: db}
 }
 
-// GetTodayDueCount returns the number of words due for review today
-func (r *StatsRepository) GetTodayDueCount(ctx context.Context, userID int64) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM user_words
-		WHERE user_id = $1 
-		  AND status = 'learning'
-		  AND (next_review_at IS NULL OR next_review_at <= NOW())
-	`
-	
-	var count int
-	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get today due count: %w", err)
-	}
-	
-	return count, nil
-}
+// RecentWordsLimit is the number of recently collected words on the dashboard
+const RecentWordsLimit = 5
 
-// GetTodayNewCount returns the number of words added today
-func (r *StatsRepository) GetTodayNewCount(ctx context.Context, userID int64) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM user_words
-		WHERE user_id = $1 
-		  AND DATE(created_at) = CURRENT_DATE
-	`
-	
-	var count int
-	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get today new count: %w", err)
-	}
-	
-	return count, nil
-}
+// weeklyStatsDays is the number of study days, ending today, in the
+// dashboard's weekly chart
+const weeklyStatsDays = 7
 
-// GetMasteredCount returns the number of mastered words (review_count >= 5)
-func (r *StatsRepository) GetMasteredCount(ctx context.Context, userID int64) (int, error) {
-	query := `
-		SELECT COUNT(*)
-		FROM user_words
-		WHERE user_id = $1 
-		  AND review_count >= 5
-	`
-	
-	var count int
-	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&count)
-	if err != nil {
-		return 0, fmt.Errorf("failed to get mastered count: %w", err)
-	}
-	
-	return count, nil
-}
-
-// GetStreakDays returns the number of consecutive days the user has studied
-func (r *StatsRepository) GetStreakDays(ctx context.Context, userID int64) (int, error) {
-	query := `
-		WITH daily_activity AS (
-			SELECT DISTINCT DATE(last_reviewed_at) as activity_date
-			FROM user_words
-			WHERE user_id = $1 
-			  AND last_reviewed_at IS NOT NULL
-			ORDER BY activity_date DESC
-		)
-		SELECT COUNT(*) as streak
-		FROM (
-			SELECT activity_date,
-			       activity_date - (ROW_NUMBER() OVER (ORDER BY activity_date DESC))::int as grp
-			FROM daily_activity
-		) grouped
-		WHERE grp = (
-			SELECT activity_date - (ROW_NUMBER() OVER (ORDER BY activity_date DESC))::int
-			FROM daily_activity
-			LIMIT 1
-		)
-	`
-	
-	var streak int
-	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(&streak)
-	if err != nil {
-		// If no activity found, return 0 instead of error
-		return 0, nil
-	}
-	
-	return streak, nil
+// Dashboard is the summary of a user's learning shown on the dashboard
+type Dashboard struct {
+	TodayDue      int          `json:"today_due"`
+	TodayNew      int          `json:"today_new"`
+	TotalMastered int          `json:"total_mastered"`
+	StreakDays    int          `json:"streak_days"`
+	RecentWords   []RecentWord `json:"recent_words"`
+	WeeklyStats   []DailyStat  `json:"weekly_stats"`
 }
 
 // RecentWord represents a recently collected word with its details
 type RecentWord struct {
-	WordID      int64     `json:"word_id"`
-	Word        string    `json:"word"`
-	Definition  string    `json:"definition"`
-	CreatedAt   time.Time `json:"created_at"`
-}
-
-// GetRecentWords returns the most recently collected words
-func (r *StatsRepository) GetRecentWords(ctx context.Context, userID int64, limit int) ([]RecentWord, error) {
-	query := `
-		SELECT uw.word_id, w.word, w.definition, uw.created_at
-		FROM user_words uw
-		JOIN words w ON uw.word_id = w.id
-		WHERE uw.user_id = $1
-		ORDER BY uw.created_at DESC
-		LIMIT $2
-	`
-	
-	rows, err := r.db.Pool.Query(ctx, query, userID, limit)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get recent words: %w", err)
-	}
-	defer rows.Close()
-	
-	var words []RecentWord
-	for rows.Next() {
-		var word RecentWord
-		err := rows.Scan(&word.WordID, &word.Word, &word.Definition, &word.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan recent word: %w", err)
-		}
-		words = append(words, word)
-	}
-	
-	return words, nil
+	WordID     uuid.UUID `json:"word_id"`
+	Word       string    `json:"word"`
+	Definition string    `json:"definition"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // DailyStat represents daily learning statistics
@@ -146,40 +48,164 @@ type DailyStat struct {
 	Count int    `json:"count"`
 }
 
-// GetWeeklyStats returns the last 7 days of learning statistics
-func (r *StatsRepository) GetWeeklyStats(ctx context.Context, userID int64) ([]DailyStat, error) {
+// GetDashboard computes the user's dashboard in a single query. Today's
+// figures are counted live, so they are current as soon as a review or word
+// is committed; earlier days of the week and the streak up to yesterday come
+// from daily_stats, and are rolled up first in the rare case that the stats
+// aggregator has not reached them yet.
+func (r *StatsRepository) GetDashboard(ctx context.Context, userID string) (*Dashboard, error) {
+	// Time zones are validated when the profile is updated, so unlike
+	// getDayBoundary the query trusts the stored name
 	query := `
-		WITH date_series AS (
-			SELECT generate_series(
-				CURRENT_DATE - INTERVAL '6 days',
-				CURRENT_DATE,
-				'1 day'::interval
-			)::date as date
+		WITH boundary AS (
+			SELECT COALESCE(NULLIF(p.timezone, ''), 'UTC') AS tz, p.day_rollover_hour::int AS hour, TRUE AS has_profile
+			FROM profiles p
+			WHERE p.user_id = $1
+			UNION ALL
+			SELECT 'UTC', $2::int, FALSE
+			ORDER BY has_profile DESC
+			LIMIT 1
+		),
+		today AS (
+			SELECT tz, hour, has_profile, ` + studyDateSQL("NOW()", "tz", "hour") + ` AS today
+			FROM boundary
+		),
+		bounds AS (
+			SELECT
+				has_profile,
+				today,
+				(today + make_interval(hours => hour)) AT TIME ZONE tz AS day_start,
+				(today + 1 + make_interval(hours => hour)) AT TIME ZONE tz AS day_end
+			FROM today
 		)
-		SELECT 
-			TO_CHAR(ds.date, 'Mon') as day_name,
-			COALESCE(COUNT(uw.id), 0) as count
-		FROM date_series ds
-		LEFT JOIN user_words uw ON DATE(uw.last_reviewed_at) = ds.date AND uw.user_id = $1
-		GROUP BY ds.date, day_name
-		ORDER BY ds.date
+		SELECT
+			b.has_profile,
+			b.today,
+			(
+				SELECT COUNT(*)
+				FROM review_cards rc
+				JOIN user_words uw ON rc.user_word_id = uw.id
+				WHERE rc.user_id = $1
+				  AND (rc.next_review_at IS NULL OR rc.next_review_at < b.day_end)
+				  AND uw.suspended_at IS NULL
+				  AND (uw.buried_until IS NULL OR uw.buried_until < b.day_end)
+			),
+			(
+				SELECT COUNT(*)
+				FROM user_words uw
+				WHERE uw.user_id = $1
+				  AND uw.created_at >= b.day_start AND uw.created_at < b.day_end
+			),
+			(
+				SELECT COUNT(*)
+				FROM user_words uw
+				WHERE uw.user_id = $1
+				  AND uw.is_mastered
+			),
+			(
+				SELECT COUNT(*)
+				FROM review_logs rl
+				JOIN review_cards rc ON rl.card_id = rc.id
+				WHERE rc.user_id = $1
+				  AND rl.reviewed_at >= b.day_start AND rl.reviewed_at < b.day_end
+			),
+			ARRAY(
+				SELECT ds.stat_date
+				FROM daily_stats ds
+				WHERE ds.user_id = $1
+				  AND ds.stat_date >= b.today - $3::int AND ds.stat_date < b.today
+				ORDER BY ds.stat_date
+			),
+			ARRAY(
+				SELECT ds.reviewed
+				FROM daily_stats ds
+				WHERE ds.user_id = $1
+				  AND ds.stat_date >= b.today - $3::int AND ds.stat_date < b.today
+				ORDER BY ds.stat_date
+			),
+			COALESCE((
+				SELECT ds.streak_days
+				FROM daily_stats ds
+				WHERE ds.user_id = $1
+				  AND ds.stat_date = b.today - 1
+			), 0),
+			COALESCE((
+				SELECT json_agg(recent ORDER BY recent.created_at DESC)
+				FROM (
+					SELECT uw.word_id, w.word, COALESCE(w.definitions->0->>'meaning', '') AS definition, uw.created_at
+					FROM user_words uw
+					JOIN words w ON uw.word_id = w.id
+					WHERE uw.user_id = $1
+					ORDER BY uw.created_at DESC
+					LIMIT $4
+				) recent
+			), '[]'::json)
+		FROM bounds b
 	`
-	
-	rows, err := r.db.Pool.Query(ctx, query, userID)
+
+	var (
+		dashboard    Dashboard
+		hasProfile   bool
+		today        time.Time
+		todayReviews int
+		pastDates    []time.Time
+		pastReviews  []int
+		prevStreak   int
+		recentJSON   []byte
+	)
+	err := r.db.Pool.QueryRow(ctx, query, userID, DefaultRolloverHour, weeklyStatsDays-1, RecentWordsLimit).Scan(
+		&hasProfile,
+		&today,
+		&dashboard.TodayDue,
+		&dashboard.TodayNew,
+		&dashboard.TotalMastered,
+		&todayReviews,
+		&pastDates,
+		&pastReviews,
+		&prevStreak,
+		&recentJSON,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get weekly stats: %w", err)
+		return nil, fmt.Errorf("failed to get dashboard: %w", err)
 	}
-	defer rows.Close()
-	
-	var stats []DailyStat
-	for rows.Next() {
-		var stat DailyStat
-		err := rows.Scan(&stat.Date, &stat.Count)
+
+	if err := json.Unmarshal(recentJSON, &dashboard.RecentWords); err != nil {
+		return nil, fmt.Errorf("failed to decode recent words: %w", err)
+	}
+
+	from := today.AddDate(0, 0, 1-weeklyStatsDays)
+	yesterday := today.AddDate(0, 0, -1)
+	reviewed := make(map[string]int, len(pastDates))
+	for i, date := range pastDates {
+		reviewed[date.Format("2006-01-02")] = pastReviews[i]
+	}
+
+	// Users without a profile have no daily_stats to roll up
+	if hasProfile && len(pastDates) < weeklyStatsDays-1 {
+		days, err := r.GetDailyStats(ctx, userID, from, yesterday)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan daily stat: %w", err)
+			return nil, err
+		}
+		prevStreak = 0
+		for _, day := range days {
+			reviewed[day.Date.Format("2006-01-02")] = day.Reviewed
+			if day.Date.Equal(yesterday) {
+				prevStreak = day.StreakDays
+			}
 		}
-		stats = append(stats, stat)
 	}
-	
-	return stats, nil
+
+	reviewed[today.Format("2006-01-02")] = todayReviews
+	dashboard.WeeklyStats = make([]DailyStat, 0, weeklyStatsDays)
+	for date := from; !date.After(today); date = date.AddDate(0, 0, 1) {
+		dashboard.WeeklyStats = append(dashboard.WeeklyStats, DailyStat{Date: date.Format("Mon"), Count: reviewed[date.Format("2006-01-02")]})
+	}
+
+	// The streak is broken once a whole study day passes without reviews
+	dashboard.StreakDays = prevStreak
+	if todayReviews > 0 {
+		dashboard.StreakDays++
+	}
+
+	return &dashboard, nil
 }
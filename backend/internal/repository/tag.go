@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrTagNameTaken is returned when a user already has a tag with the name
+var ErrTagNameTaken = errors.New("a tag with this name already exists")
+
+// Tag is a user's label for words with the number of words carrying it
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	WordCount int       `json:"word_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TagUpdate holds the fields of a tag to change; nil fields are kept
+type TagUpdate struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
+
+type TagRepository struct {
+	db *DB
+}
+
+func NewTagRepository(db *DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// tagColumns are the tags (t) columns and count scanned by queryTags
+const tagColumns = `
+	t.id,
+	t.name,
+	COALESCE(t.color, ''),
+	(SELECT COUNT(*) FROM user_word_tags uwt WHERE uwt.tag_id = t.id),
+	t.created_at,
+	t.updated_at
+`
+
+// ListTags returns the user's tags by name
+func (r *TagRepository) ListTags(ctx context.Context, userID string) ([]Tag, error) {
+	return r.queryTags(ctx, userID, `ORDER BY lower(t.name), t.id`)
+}
+
+// GetTag returns one of the user's tags, or nil if the user has no such tag
+func (r *TagRepository) GetTag(ctx context.Context, userID string, tagID uuid.UUID) (*Tag, error) {
+	tags, err := r.queryTags(ctx, userID, `AND t.id = $2`, tagID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return &tags[0], nil
+}
+
+// AutocompleteTags returns up to limit of the user's tags whose name starts
+// with prefix, ignoring case and a leading #, most used first
+func (r *TagRepository) AutocompleteTags(ctx context.Context, userID, prefix string, limit int) ([]Tag, error) {
+	pattern := likeEscaper.Replace(normalizeTagName(prefix)) + "%"
+
+	return r.queryTags(ctx, userID, `
+		AND lower(ltrim(t.name, '#')) LIKE $2
+		ORDER BY 4 DESC, lower(t.name), t.id
+		LIMIT $3
+	`, pattern, limit)
+}
+
+// queryTags selects the user's tags with tagColumns; rest follows the WHERE
+// clause and may refer to extra arguments from $2
+func (r *TagRepository) queryTags(ctx context.Context, userID, rest string, extra ...interface{}) ([]Tag, error) {
+	query := `
+		SELECT ` + tagColumns + `
+		FROM tags t
+		WHERE t.user_id = $1
+		` + rest
+
+	args := append([]interface{}{userID}, extra...)
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []Tag{}
+	for rows.Next() {
+		var tag Tag
+		err := rows.Scan(
+			&tag.ID,
+			&tag.Name,
+			&tag.Color,
+			&tag.WordCount,
+			&tag.CreatedAt,
+			&tag.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// CreateTag adds a tag for the user
+func (r *TagRepository) CreateTag(ctx context.Context, userID, name, color string) (*Tag, error) {
+	query := `
+		INSERT INTO tags (user_id, name, color)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`
+
+	var tagID uuid.UUID
+	err := r.db.Pool.QueryRow(ctx, query, userID, name, color).Scan(&tagID)
+	if isUniqueViolation(err) {
+		return nil, ErrTagNameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return r.GetTag(ctx, userID, tagID)
+}
+
+// UpdateTag renames or recolours one of the user's tags. It returns nil if
+// the user has no such tag.
+func (r *TagRepository) UpdateTag(ctx context.Context, userID string, tagID uuid.UUID, update TagUpdate) (*Tag, error) {
+	query := `
+		UPDATE tags
+		SET
+			name = COALESCE($3, name),
+			color = COALESCE($4, color),
+			updated_at = NOW()
+		WHERE user_id = $1 AND id = $2
+		RETURNING id
+	`
+
+	var id uuid.UUID
+	err := r.db.Pool.QueryRow(ctx, query, userID, tagID, update.Name, update.Color).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrTagNameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	return r.GetTag(ctx, userID, tagID)
+}
+
+// DeleteTag removes one of the user's tags from the tag list and from every
+// word. It reports whether the tag existed.
+func (r *TagRepository) DeleteTag(ctx context.Context, userID string, tagID uuid.UUID) (bool, error) {
+	result, err := r.db.Pool.Exec(ctx, `DELETE FROM tags WHERE user_id = $1 AND id = $2`, userID, tagID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// CountTags returns how many of tagIDs are the user's tags
+func (r *TagRepository) CountTags(ctx context.Context, userID string, tagIDs []uuid.UUID) (int, error) {
+	var count int
+	err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM tags WHERE user_id = $1 AND id = ANY($2)`, userID, tagIDs).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tags: %w", err)
+	}
+	return count, nil
+}
+
+// AttachTags tags every word selected by filter with each of the user's
+// tags in tagIDs, skipping tags the words already have. It returns the
+// number of words matched; the caller checks that the tags are the user's.
+func (r *TagRepository) AttachTags(ctx context.Context, userID string, tagIDs []uuid.UUID, filter WordFilter) (int, error) {
+	return r.updateTags(ctx, userID, filter, `
+		INSERT INTO user_word_tags (user_word_id, tag_id)
+		SELECT w.id, t.id
+		FROM unnest($2::uuid[]) AS w(id)
+		CROSS JOIN tags t
+		WHERE t.user_id = $1 AND t.id = ANY($3)
+		ON CONFLICT (user_word_id, tag_id) DO NOTHING
+	`, tagIDs)
+}
+
+// DetachTags removes each of tagIDs from every word selected by filter. It
+// returns the number of words matched.
+func (r *TagRepository) DetachTags(ctx context.Context, userID string, tagIDs []uuid.UUID, filter WordFilter) (int, error) {
+	return r.updateTags(ctx, userID, filter, `
+		DELETE FROM user_word_tags uwt
+		USING tags t
+		WHERE uwt.tag_id = t.id
+		  AND t.user_id = $1
+		  AND uwt.user_word_id = ANY($2)
+		  AND uwt.tag_id = ANY($3)
+	`, tagIDs)
+}
+
+// updateTags runs a statement changing the tags of the words selected by
+// filter in one transaction. The statement gets the user ID as $1, the
+// matched word IDs as $2 and tagIDs as $3.
+func (r *TagRepository) updateTags(ctx context.Context, userID string, filter WordFilter, query string, tagIDs []uuid.UUID) (int, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Resolve the filter first, so that tag filters are not affected by the update
+	ids, err := matchWords(ctx, tx, userID, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	if _, err := tx.Exec(ctx, query, userID, ids, tagIDs); err != nil {
+		return 0, fmt.Errorf("failed to update word tags: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidTagQuery is returned for tag queries that do not parse
+var ErrInvalidTagQuery = errors.New("invalid tag query")
+
+// tagTerm matches words tagged with name, ignoring case and a leading #
+type tagTerm struct {
+	name string
+}
+
+func (t tagTerm) sql(args []interface{}) (string, []interface{}) {
+	args = append(args, normalizeTagName(t.name))
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM user_word_tags uwt
+		JOIN tags t ON uwt.tag_id = t.id
+		WHERE uwt.user_word_id = uw.id
+		  AND lower(ltrim(t.name, '#')) = $%d
+	)`, len(args)), args
+}
+
+// normalizeTagName is the form tag names are compared in
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimLeft(strings.TrimSpace(name), "#"))
+}
+
+// parseTagTerm parses a tag query term, tag:name, where name may be
+// double-quoted to include spaces or parentheses
+func parseTagTerm(text string) (queryExpr, error) {
+	name, ok := cutPrefixFold(text, "tag:")
+	if !ok {
+		return nil, fmt.Errorf("%w: expected tag:name, got %q", ErrInvalidTagQuery, text)
+	}
+	if normalizeTagName(name) == "" {
+		return nil, fmt.Errorf("%w: empty tag name", ErrInvalidTagQuery)
+	}
+	return tagTerm{name: name}, nil
+}
+
+// parseTagQuery parses a boolean tag query such as
+// `tag:gre AND NOT (tag:done OR tag:"phrasal verbs")`
+func parseTagQuery(query string) (queryExpr, error) {
+	return parseQuery(query, parseTagTerm, ErrInvalidTagQuery)
+}
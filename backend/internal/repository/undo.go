@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Undo errors
+var (
+	ErrNothingToUndo = errors.New("no review to undo")
+	// ErrUndoOutOfOrder is returned when the card was reviewed again after
+	// the review being undone, outside the session
+	ErrUndoOutOfOrder = errors.New("a later review of this card must be undone first")
+	// ErrCardReset is returned when the card's progress was reset after the review
+	ErrCardReset = errors.New("the card was reset after this review")
+	// ErrUndoLimit is returned outside a session when the latest review is
+	// from an earlier study day or an undo was already made after it
+	ErrUndoLimit = errors.New("outside a session only one review from today can be undone")
+)
+
+// UndoneReview describes a review that was reverted
+type UndoneReview struct {
+	ReviewLogID uuid.UUID    `json:"review_log_id"`
+	CardID      uuid.UUID    `json:"card_id"`
+	UserWordID  uuid.UUID    `json:"user_word_id"`
+	SessionID   *uuid.UUID   `json:"session_id"`
+	Quality     int          `json:"quality"`
+	ReviewedAt  time.Time    `json:"reviewed_at"`
+	Restored    CardSnapshot `json:"restored"`
+}
+
+// newCardSnapshot is the state of a card that has never been reviewed
+var newCardSnapshot = CardSnapshot{EasinessFactor: 2.5, CardState: "new"}
+
+// UndoLastReview reverts the user's most recent review, or the most recent
+// review of sessionID when it is set. In one transaction the review log is
+// deleted, the card's scheduling state and lapses before the review are
+// restored along with any leech flag it set, the XP is taken back and the
+// session is stepped back to that card. Sessions can be stepped back answer
+// by answer; outside a session only a review from the study day containing
+// now can be undone, and only if nothing was undone since it was made.
+func (r *ReviewRepository) UndoLastReview(ctx context.Context, userID string, sessionID *uuid.UUID, now time.Time) (*UndoneReview, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the profile also serialises concurrent undos of one user
+	profileQuery := `
+		SELECT COALESCE(timezone, 'UTC'), day_rollover_hour, last_undone_at
+		FROM profiles
+		WHERE user_id = $1
+		FOR UPDATE
+	`
+	boundary := DayBoundary{Timezone: "UTC", RolloverHour: DefaultRolloverHour}
+	var lastUndoneAt *time.Time
+	err = tx.QueryRow(ctx, profileQuery, userID).Scan(&boundary.Timezone, &boundary.RolloverHour, &lastUndoneAt)
+	if err != nil && err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to get profile: %w", err)
+	}
+	if _, err := time.LoadLocation(boundary.Timezone); err != nil {
+		boundary.Timezone = "UTC"
+	}
+
+	query := `
+		SELECT rl.id, rl.card_id, rl.user_word_id, rl.session_id, rl.quality, rl.reviewed_at, rl.created_at,
+			rl.xp_awarded, rl.requeued, rl.is_lapse, rl.leech_suspended,
+			rl.prev_easiness_factor, rl.prev_interval, rl.prev_repetitions,
+			rl.prev_stability, rl.prev_difficulty, rl.prev_card_state, rl.prev_learning_step,
+			rl.prev_last_reviewed_at, rl.prev_next_review_at
+		FROM review_logs rl
+		JOIN user_words uw ON rl.user_word_id = uw.id
+		WHERE uw.user_id = $1
+		  AND ($2::uuid IS NULL OR rl.session_id = $2)
+		ORDER BY rl.reviewed_at DESC, rl.created_at DESC
+		LIMIT 1
+		FOR UPDATE OF rl
+	`
+
+	var (
+		undone    UndoneReview
+		createdAt time.Time
+		xpAwarded int
+		requeued  bool
+		isLapse   bool
+		suspended bool
+		prevEF    *float64
+		prevInt   *int
+		prevReps  *int
+		prevStab  *float64
+		prevDiff  *float64
+		prevState *string
+		prevStep  *int
+		prevLast  *time.Time
+		prevNext  *time.Time
+	)
+	err = tx.QueryRow(ctx, query, userID, sessionID).Scan(
+		&undone.ReviewLogID, &undone.CardID, &undone.UserWordID, &undone.SessionID, &undone.Quality, &undone.ReviewedAt, &createdAt,
+		&xpAwarded, &requeued, &isLapse, &suspended,
+		&prevEF, &prevInt, &prevReps, &prevStab, &prevDiff, &prevState, &prevStep, &prevLast, &prevNext,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNothingToUndo
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last review: %w", err)
+	}
+
+	if sessionID == nil {
+		dayStart, _ := boundary.Today(now)
+		if undone.ReviewedAt.Before(dayStart) || (lastUndoneAt != nil && !createdAt.After(*lastUndoneAt)) {
+			return nil, ErrUndoLimit
+		}
+	}
+
+	// Reviews of one card can only be undone newest first
+	var newer bool
+	newerQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM review_logs
+			WHERE card_id = $1 AND id <> $2
+			  AND (reviewed_at, created_at) > ($3, $4)
+		)
+	`
+	if err := tx.QueryRow(ctx, newerQuery, undone.CardID, undone.ReviewLogID, undone.ReviewedAt, createdAt).Scan(&newer); err != nil {
+		return nil, fmt.Errorf("failed to check later reviews: %w", err)
+	}
+	if newer {
+		return nil, ErrUndoOutOfOrder
+	}
+
+	// A card that has a review but no last review time was reset since
+	var reset bool
+	resetQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM review_cards
+			WHERE id = $1 AND last_reviewed_at IS NULL
+		)
+	`
+	if err := tx.QueryRow(ctx, resetQuery, undone.CardID).Scan(&reset); err != nil {
+		return nil, fmt.Errorf("failed to check card state: %w", err)
+	}
+	if reset {
+		return nil, ErrCardReset
+	}
+
+	if prevState != nil {
+		undone.Restored = CardSnapshot{
+			EasinessFactor: derefFloat(prevEF),
+			Interval:       derefInt(prevInt),
+			Repetitions:    derefInt(prevReps),
+			Stability:      derefFloat(prevStab),
+			Difficulty:     derefFloat(prevDiff),
+			CardState:      *prevState,
+			LearningStep:   derefInt(prevStep),
+			LastReviewedAt: prevLast,
+			NextReviewAt:   prevNext,
+		}
+	} else {
+		undone.Restored, err = stateAfterPreviousLog(ctx, tx, undone.CardID, undone.ReviewLogID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	restoreQuery := `
+		UPDATE review_cards
+		SET
+			easiness_factor = $2,
+			interval = $3,
+			repetitions = $4,
+			stability = $5,
+			difficulty = $6,
+			card_state = $7,
+			learning_step = $8,
+			last_reviewed_at = $9,
+			next_review_at = $10,
+			lapses = CASE WHEN $11 THEN GREATEST(lapses - 1, 0) ELSE lapses END,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	restored := undone.Restored
+	if _, err := tx.Exec(ctx, restoreQuery,
+		undone.CardID,
+		restored.EasinessFactor,
+		restored.Interval,
+		restored.Repetitions,
+		restored.Stability,
+		restored.Difficulty,
+		restored.CardState,
+		restored.LearningStep,
+		restored.LastReviewedAt,
+		restored.NextReviewAt,
+		isLapse,
+	); err != nil {
+		return nil, fmt.Errorf("failed to restore review card: %w", err)
+	}
+	if _, err := tx.Exec(ctx, syncUserWordQuery, undone.UserWordID); err != nil {
+		return nil, fmt.Errorf("failed to update user word: %w", err)
+	}
+	if isLapse {
+		if err := unmarkLeech(ctx, tx, undone.UserWordID, suspended); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM review_logs WHERE id = $1`, undone.ReviewLogID); err != nil {
+		return nil, fmt.Errorf("failed to delete review log: %w", err)
+	}
+
+	if xpAwarded > 0 {
+		xpQuery := `
+			UPDATE profiles
+			SET xp = GREATEST(xp - $2, 0), updated_at = NOW()
+			WHERE user_id = $1
+		`
+		if _, err := tx.Exec(ctx, xpQuery, userID, xpAwarded); err != nil {
+			return nil, fmt.Errorf("failed to take back xp: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE profiles SET last_undone_at = NOW() WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to record undo: %w", err)
+	}
+
+	if undone.SessionID != nil {
+		if err := stepBackSession(ctx, tx, *undone.SessionID, undone, xpAwarded, requeued); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &undone, nil
+}
+
+// stateAfterPreviousLog rebuilds a card's state from the review before
+// excludeLogID, for logs written before undo snapshots were recorded.
+// The learning step is not logged, so it restarts at the first step.
+func stateAfterPreviousLog(ctx context.Context, tx pgx.Tx, cardID, excludeLogID uuid.UUID) (CardSnapshot, error) {
+	query := `
+		SELECT easiness_factor, interval, repetitions, COALESCE(stability, 0), COALESCE(difficulty, 0),
+			card_state, reviewed_at, next_review_at
+		FROM review_logs
+		WHERE card_id = $1 AND id <> $2
+		ORDER BY reviewed_at DESC, created_at DESC
+		LIMIT 1
+	`
+
+	var (
+		state      CardSnapshot
+		reviewedAt time.Time
+	)
+	err := tx.QueryRow(ctx, query, cardID, excludeLogID).Scan(
+		&state.EasinessFactor,
+		&state.Interval,
+		&state.Repetitions,
+		&state.Stability,
+		&state.Difficulty,
+		&state.CardState,
+		&reviewedAt,
+		&state.NextReviewAt,
+	)
+	if err == pgx.ErrNoRows {
+		return newCardSnapshot, nil
+	}
+	if err != nil {
+		return CardSnapshot{}, fmt.Errorf("failed to get previous review: %w", err)
+	}
+
+	state.LastReviewedAt = &reviewedAt
+	return state, nil
+}
+
+// stepBackSession moves a session back to the card of an undone answer
+func stepBackSession(ctx context.Context, tx pgx.Tx, sessionID uuid.UUID, undone UndoneReview, xpAwarded int, requeued bool) error {
+	query := `
+		SELECT ` + sessionColumns + `
+		FROM review_sessions
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	session, err := scanSession(tx.QueryRow(ctx, query, sessionID))
+	if err == pgx.ErrNoRows {
+		// The session was deleted; the review itself is still undone
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get review session: %w", err)
+	}
+
+	fromPosition := session.Position
+
+	// Drop the repeat the answer queued at the end of the session
+	if last := len(session.CardIDs) - 1; requeued && last >= 0 && session.CardIDs[last] == undone.CardID {
+		session.CardIDs = session.CardIDs[:last]
+	}
+	if session.Position > 0 {
+		session.Position--
+	}
+	if session.Answered > 0 {
+		session.Answered--
+	}
+	if undone.Quality >= 3 && session.Correct > 0 {
+		session.Correct--
+	}
+	session.XPEarned -= xpAwarded
+	if session.XPEarned < 0 {
+		session.XPEarned = 0
+	}
+	session.Status = SessionStatusActive
+	session.CompletedAt = nil
+
+	lastAnsweredQuery := `
+		SELECT MAX(reviewed_at)
+		FROM review_logs
+		WHERE session_id = $1
+	`
+	if err := tx.QueryRow(ctx, lastAnsweredQuery, sessionID).Scan(&session.LastAnsweredAt); err != nil {
+		return fmt.Errorf("failed to get last session answer: %w", err)
+	}
+
+	return updateSessionProgress(ctx, tx, session, fromPosition)
+}
+
+func derefFloat(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func derefInt(v *int) int {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
@@ -9,13 +9,58 @@ import (
 )
 
 type User struct {
-	ID              string    `json:"id"`
-	Email           string    `json:"email"`
-	DisplayName     string    `json:"display_name"`
-	Timezone        string    `json:"timezone"`
-	DailyReviewGoal int       `json:"daily_review_goal"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	DisplayName      string    `json:"display_name"`
+	Timezone         string    `json:"timezone"`
+	DailyReviewGoal  int       `json:"daily_review_goal"`
+	Scheduler        string    `json:"scheduler"`
+	LearningSteps    []int     `json:"learning_steps"`
+	RelearningSteps  []int     `json:"relearning_steps"`
+	DesiredRetention float64   `json:"desired_retention"`
+	DayRolloverHour  int       `json:"day_rollover_hour"`
+	LeechThreshold   int       `json:"leech_threshold"`
+	LeechAction      string    `json:"leech_action"`
+	NewCardsPerDay   int       `json:"new_cards_per_day"`
+	ReviewsPerDay    int       `json:"reviews_per_day"`
+	NewCardRatio     float64   `json:"new_card_ratio"`
+	NewCardOrder     string    `json:"new_card_order"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// profileColumns lists the profiles columns scanned by scanUser, in order
+const profileColumns = `id, email, display_name, timezone, daily_review_goal, scheduler,
+		learning_steps, relearning_steps, desired_retention, day_rollover_hour, leech_threshold, leech_action,
+		new_cards_per_day, reviews_per_day, new_card_ratio::float8, new_card_order, created_at, updated_at`
+
+// scanUser scans a profiles row selected with profileColumns
+func scanUser(row pgx.Row) (*User, error) {
+	var user User
+	err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.DisplayName,
+		&user.Timezone,
+		&user.DailyReviewGoal,
+		&user.Scheduler,
+		&user.LearningSteps,
+		&user.RelearningSteps,
+		&user.DesiredRetention,
+		&user.DayRolloverHour,
+		&user.LeechThreshold,
+		&user.LeechAction,
+		&user.NewCardsPerDay,
+		&user.ReviewsPerDay,
+		&user.NewCardRatio,
+		&user.NewCardOrder,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
 type UserRepository struct {
@@ -26,24 +71,15 @@ func NewUserRepository(db *DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
-// GetUserByID retrieves a user by their ID
+// GetUserByID retrieves a user's profile by their user ID
 func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*User, error) {
 	query := `
-		SELECT id, email, display_name, timezone, daily_review_goal, created_at, updated_at
+		SELECT ` + profileColumns + `
 		FROM profiles
-		WHERE id = $1
+		WHERE user_id = $1
 	`
 
-	var user User
-	err := r.db.Pool.QueryRow(ctx, query, userID).Scan(
-		&user.ID,
-		&user.Email,
-		&user.DisplayName,
-		&user.Timezone,
-		&user.DailyReviewGoal,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID))
 
 	if err == pgx.ErrNoRows {
 		return nil, nil // User not found
@@ -52,58 +88,186 @@ func (r *UserRepository) GetUserByID(ctx context.Context, userID string) (*User,
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
 }
 
 // CreateUser creates a new user profile
 func (r *UserRepository) CreateUser(ctx context.Context, userID, email string) (*User, error) {
 	query := `
-		INSERT INTO profiles (id, email, display_name, timezone, daily_review_goal)
+		INSERT INTO profiles (user_id, email, display_name, timezone, daily_review_goal)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, email, display_name, timezone, daily_review_goal, created_at, updated_at
+		RETURNING ` + profileColumns + `
 	`
 
-	var user User
-	err := r.db.Pool.QueryRow(ctx, query, userID, email, "", "UTC", 20).Scan(
-		&user.ID,
-		&user.Email,
-		&user.DisplayName,
-		&user.Timezone,
-		&user.DailyReviewGoal,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, email, "", "UTC", 20))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// ProfileUpdate holds the profile information to change; nil fields keep
+// their current value
+type ProfileUpdate struct {
+	DisplayName     *string
+	Timezone        *string
+	DailyReviewGoal *int
 }
 
-// UpdateUser updates user profile information
-func (r *UserRepository) UpdateUser(ctx context.Context, userID string, displayName, timezone string, dailyReviewGoal int) (*User, error) {
+// IsEmpty reports whether the update changes nothing
+func (u ProfileUpdate) IsEmpty() bool {
+	return u.DisplayName == nil && u.Timezone == nil && u.DailyReviewGoal == nil
+}
+
+// UpdateUser updates the user profile information set in update
+func (r *UserRepository) UpdateUser(ctx context.Context, userID string, update ProfileUpdate) (*User, error) {
 	query := `
 		UPDATE profiles
-		SET display_name = $2, timezone = $3, daily_review_goal = $4, updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, email, display_name, timezone, daily_review_goal, created_at, updated_at
+		SET
+			display_name = COALESCE($2, display_name),
+			timezone = COALESCE($3, timezone),
+			daily_review_goal = COALESCE($4, daily_review_goal),
+			updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
 	`
 
-	var user User
-	err := r.db.Pool.QueryRow(ctx, query, userID, displayName, timezone, dailyReviewGoal).Scan(
-		&user.ID,
-		&user.Email,
-		&user.DisplayName,
-		&user.Timezone,
-		&user.DailyReviewGoal,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, update.DisplayName, update.Timezone, update.DailyReviewGoal))
 
+	if err == pgx.ErrNoRows {
+		return nil, nil // User not found
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// UpdateScheduler sets the spaced repetition algorithm used for the user's reviews
+func (r *UserRepository) UpdateScheduler(ctx context.Context, userID string, scheduler string) (*User, error) {
+	query := `
+		UPDATE profiles
+		SET scheduler = $2, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
+	`
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, scheduler))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user scheduler: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateLearningSteps sets the user's learning and relearning step lists (in minutes)
+func (r *UserRepository) UpdateLearningSteps(ctx context.Context, userID string, learningSteps, relearningSteps []int) (*User, error) {
+	query := `
+		UPDATE profiles
+		SET learning_steps = $2, relearning_steps = $3, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
+	`
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, learningSteps, relearningSteps))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to update learning steps: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateDesiredRetention sets the recall probability the user's intervals are scheduled for
+func (r *UserRepository) UpdateDesiredRetention(ctx context.Context, userID string, desiredRetention float64) (*User, error) {
+	query := `
+		UPDATE profiles
+		SET desired_retention = $2, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
+	`
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, desiredRetention))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update desired retention: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateDayRolloverHour sets the local hour at which the user's study day starts
+func (r *UserRepository) UpdateDayRolloverHour(ctx context.Context, userID string, hour int) (*User, error) {
+	query := `
+		UPDATE profiles
+		SET day_rollover_hour = $2, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
+	`
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update day rollover hour: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateLeechSettings sets how many lapses make a word a leech and whether
+// leeches are suspended, and re-evaluates the leech flag of the user's words
+// against the new threshold
+func (r *UserRepository) UpdateLeechSettings(ctx context.Context, userID string, threshold int, action string) (*User, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE profiles
+		SET leech_threshold = $2, leech_action = $3, updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
+	`
+
+	user, err := scanUser(tx.QueryRow(ctx, query, userID, threshold, action))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update leech settings: %w", err)
+	}
+
+	if err := reevaluateLeeches(ctx, tx, userID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return user, nil
+}
+
+// UpdateStudyLimits sets the user's daily new card and review limits and how
+// new cards are mixed into their reviews
+func (r *UserRepository) UpdateStudyLimits(ctx context.Context, userID string, limits StudyLimits) (*User, error) {
+	query := `
+		UPDATE profiles
+		SET
+			new_cards_per_day = $2,
+			reviews_per_day = $3,
+			new_card_ratio = $4,
+			new_card_order = $5,
+			updated_at = NOW()
+		WHERE user_id = $1
+		RETURNING ` + profileColumns + `
+	`
+
+	user, err := scanUser(r.db.Pool.QueryRow(ctx, query, userID, limits.NewCardsPerDay, limits.ReviewsPerDay, limits.NewCardRatio, limits.NewCardOrder))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update study limits: %w", err)
+	}
+
+	return user, nil
 }
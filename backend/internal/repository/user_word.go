@@ -46,6 +46,14 @@ func (r *UserWordRepository) AddUserWord(ctx context.Context, userID, wordID int
 	return userWord, nil
 }
 
+// userWordSortColumns are the columns ListUserWords can sort by
+var userWordSortColumns = map[string]bool{
+	"created_at":       true,
+	"updated_at":       true,
+	"last_reviewed_at": true,
+	"next_review_at":   true,
+}
+
 // ListUserWords retrieves user's words with filters
 func (r *UserWordRepository) ListUserWords(ctx context.Context, userID int64, filters map[string]interface{}) ([]*model.UserWord, error) {
 	queryBuilder := strings.Builder{}
@@ -68,8 +76,9 @@ func (r *UserWordRepository) ListUserWords(ctx context.Context, userID int64, fi
 	}
 	
 	// Sorting
+	// The sort column is interpolated, so only known columns are accepted
 	sortBy := "created_at"
-	if sort, ok := filters["sort"].(string); ok && sort != "" {
+	if sort, ok := filters["sort"].(string); ok && userWordSortColumns[sort] {
 		sortBy = sort
 	}
 	
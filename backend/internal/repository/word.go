@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"vocabweb/internal/model"
+
+	"github.com/google/uuid"
 )
 
 type WordRepository struct {
@@ -69,46 +71,105 @@ func (r *WordRepository) GetWordByText(ctx context.Context, text string) (*model
 	return word, nil
 }
 
-// SearchWords performs fuzzy search on words
-func (r *WordRepository) SearchWords(ctx context.Context, query string, limit, offset int) ([]*model.Word, error) {
+// DictionaryEntry is a word of the global dictionary found by a search
+type DictionaryEntry struct {
+	ID            uuid.UUID `json:"id"`
+	Word          string    `json:"word"`
+	Phonetic      string    `json:"phonetic"`
+	Definitions   string    `json:"definitions"` // JSONB as string
+	FrequencyRank *int      `json:"frequency_rank"`
+	AudioURL      string    `json:"audio_url"`
+	// Match is how the word matched: exact, prefix, fuzzy or text
+	Match string  `json:"match"`
+	Score float64 `json:"score"`
+	// UserWordID is the searching user's copy of the word, if collected
+	UserWordID *uuid.UUID `json:"user_word_id"`
+}
+
+// Dictionary entry matches, from the strongest
+const (
+	DictionaryMatchExact  = "exact"
+	DictionaryMatchPrefix = "prefix"
+	DictionaryMatchFuzzy  = "fuzzy"
+	DictionaryMatchText   = "text"
+)
+
+// SearchWords finds up to limit dictionary words for a query, best first:
+// the word itself, then words starting with the query, then words within
+// trigram similarity of it (typos) and, unless prefixOnly, words whose
+// meanings or examples match it as full text. Common words rank higher by
+// frequency_rank. userID marks the words the user has collected.
+func (r *WordRepository) SearchWords(ctx context.Context, userID, query string, limit int, prefixOnly bool) ([]DictionaryEntry, error) {
 	sqlQuery := `
-		SELECT id, word, language, part_of_speech, definition, pronunciation, created_at, updated_at
-		FROM words
-		WHERE LOWER(word) LIKE LOWER($1) OR LOWER(definition) LIKE LOWER($1)
-		ORDER BY word ASC
-		LIMIT $2 OFFSET $3
+		WITH input AS (
+			SELECT lower($2) AS term, websearch_to_tsquery('english', $2) AS tsq
+		),
+		matches AS (
+			SELECT
+				w.*,
+				CASE
+					WHEN lower(w.word) = i.term THEN 'exact'
+					WHEN lower(w.word) LIKE $3 THEN 'prefix'
+					WHEN NOT $5 AND lower(w.word) % i.term THEN 'fuzzy'
+					ELSE 'text'
+				END AS match,
+				similarity(lower(w.word), i.term) AS similarity,
+				CASE WHEN $5 THEN 0 ELSE ts_rank(w.search_vector, i.tsq) END AS text_rank
+			FROM words w, input i
+			WHERE lower(w.word) LIKE $3
+			   OR (NOT $5 AND (lower(w.word) % i.term OR w.search_vector @@ i.tsq))
+		)
+		SELECT
+			m.id,
+			m.word,
+			COALESCE(m.phonetic, ''),
+			m.definitions::text,
+			m.frequency_rank,
+			COALESCE(m.audio_url, ''),
+			m.match,
+			(CASE m.match WHEN 'exact' THEN 3 WHEN 'prefix' THEN 1 ELSE 0 END
+				+ m.similarity
+				+ LEAST(m.text_rank, 1)
+				+ COALESCE(0.5 / log(m.frequency_rank + 9), 0))::float8 AS score,
+			uw.id
+		FROM matches m
+		LEFT JOIN user_words uw ON uw.word_id = m.id AND uw.user_id = $1
+		ORDER BY score DESC, m.frequency_rank ASC NULLS LAST, m.word ASC
+		LIMIT $4
 	`
-	
-	searchPattern := "%" + strings.TrimSpace(query) + "%"
-	
-	rows, err := r.db.Pool.Query(ctx, sqlQuery, searchPattern, limit, offset)
+
+	// Escape LIKE wildcards in the query so that prefixes match literally
+	prefix := likeEscaper.Replace(strings.ToLower(strings.TrimSpace(query))) + "%"
+
+	rows, err := r.db.Pool.Query(ctx, sqlQuery, userID, strings.TrimSpace(query), prefix, limit, prefixOnly)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search words: %w", err)
 	}
 	defer rows.Close()
-	
-	var words []*model.Word
+
+	entries := []DictionaryEntry{}
 	for rows.Next() {
-		word := &model.Word{}
+		var entry DictionaryEntry
 		err := rows.Scan(
-			&word.ID,
-			&word.Word,
-			&word.Language,
-			&word.PartOfSpeech,
-			&word.Definition,
-			&word.Pronunciation,
-			&word.CreatedAt,
-			&word.UpdatedAt,
+			&entry.ID,
+			&entry.Word,
+			&entry.Phonetic,
+			&entry.Definitions,
+			&entry.FrequencyRank,
+			&entry.AudioURL,
+			&entry.Match,
+			&entry.Score,
+			&entry.UserWordID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan word: %w", err)
 		}
-		words = append(words, word)
+		entries = append(entries, entry)
 	}
-	
+
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating words: %w", err)
 	}
-	
-	return words, nil
+
+	return entries, nil
 }
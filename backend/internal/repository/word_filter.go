@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrEmptyFilter is returned when a bulk operation is given a filter that
+// would select every word
+var ErrEmptyFilter = errors.New("filter must select by group, tag, tag query, status or IDs")
+
+// Word statuses that can be filtered on
+const (
+	WordStatusNew       = "new"
+	WordStatusLearning  = "learning"
+	WordStatusReview    = "review"
+	WordStatusMastered  = "mastered"
+	WordStatusSuspended = "suspended"
+	WordStatusBuried    = "buried"
+	WordStatusLeech     = "leech"
+)
+
+// wordStatusConditions maps each status to its condition on user_words (uw)
+var wordStatusConditions = map[string]string{
+	WordStatusNew:       "uw.card_state = 'new'",
+	WordStatusLearning:  "uw.card_state IN ('learning', 'relearning')",
+	WordStatusReview:    "uw.card_state = 'review'",
+	WordStatusMastered:  "uw.is_mastered",
+	WordStatusSuspended: "uw.suspended_at IS NOT NULL",
+	WordStatusBuried:    "uw.buried_until IS NOT NULL AND uw.buried_until > NOW()",
+	WordStatusLeech:     "uw.is_leech",
+}
+
+// reviewableWordSQL is the condition on user_words (uw) for words that can
+// be reviewed now: not suspended and not buried
+const reviewableWordSQL = `uw.suspended_at IS NULL AND (uw.buried_until IS NULL OR uw.buried_until <= NOW())`
+
+// WordFilter selects some of a user's words; all set fields must match
+type WordFilter struct {
+	UserWordIDs []uuid.UUID `json:"user_word_ids"`
+	GroupID     *uuid.UUID  `json:"group_id"`
+	// Ungrouped selects the words outside any group
+	Ungrouped bool       `json:"ungrouped"`
+	TagID     *uuid.UUID `json:"tag_id"`
+	// TagQuery is a boolean tag expression such as `tag:gre AND NOT tag:done`
+	TagQuery string `json:"tag_query"`
+	Status   string `json:"status"`
+}
+
+// IsEmpty reports whether the filter matches every word
+func (f WordFilter) IsEmpty() bool {
+	return len(f.UserWordIDs) == 0 && f.GroupID == nil && !f.Ungrouped && f.TagID == nil && strings.TrimSpace(f.TagQuery) == "" && f.Status == ""
+}
+
+// Validate checks the filter's status and tag query
+func (f WordFilter) Validate() error {
+	if f.GroupID != nil && f.Ungrouped {
+		return fmt.Errorf("group_id and ungrouped cannot both be set")
+	}
+	if f.Status != "" {
+		if _, ok := wordStatusConditions[f.Status]; !ok {
+			return fmt.Errorf("unknown status: %s", f.Status)
+		}
+	}
+	if strings.TrimSpace(f.TagQuery) != "" {
+		if _, err := parseTagQuery(f.TagQuery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conditions returns the filter's SQL conditions on user_words (uw), with
+// placeholders numbered after the existing args, and the extended args
+func (f WordFilter) conditions(args []interface{}) (string, []interface{}, error) {
+	if err := f.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	var conds []string
+	if len(f.UserWordIDs) > 0 {
+		args = append(args, f.UserWordIDs)
+		conds = append(conds, fmt.Sprintf("uw.id = ANY($%d)", len(args)))
+	}
+	if f.GroupID != nil {
+		args = append(args, *f.GroupID)
+		conds = append(conds, fmt.Sprintf("uw.group_id = $%d", len(args)))
+	}
+	if f.Ungrouped {
+		conds = append(conds, "uw.group_id IS NULL")
+	}
+	if f.TagID != nil {
+		args = append(args, *f.TagID)
+		conds = append(conds, fmt.Sprintf("EXISTS (SELECT 1 FROM user_word_tags uwt WHERE uwt.user_word_id = uw.id AND uwt.tag_id = $%d)", len(args)))
+	}
+	if strings.TrimSpace(f.TagQuery) != "" {
+		expr, err := parseTagQuery(f.TagQuery)
+		if err != nil {
+			return "", nil, err
+		}
+		var cond string
+		cond, args = expr.sql(args)
+		conds = append(conds, cond)
+	}
+	if f.Status != "" {
+		conds = append(conds, wordStatusConditions[f.Status])
+	}
+
+	if len(conds) == 0 {
+		return "TRUE", args, nil
+	}
+	return strings.Join(conds, " AND "), args, nil
+}
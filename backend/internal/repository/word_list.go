@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Word list sort keys
+const (
+	WordSortCreatedAt      = "created_at"
+	WordSortWord           = "word"
+	WordSortNextReviewAt   = "next_review_at"
+	WordSortLastReviewedAt = "last_reviewed_at"
+	WordSortEase           = "ease"
+	WordSortLapses         = "lapses"
+	WordSortMastery        = "mastery_level"
+)
+
+// wordSorts are the keys words can be listed by, over user_words (uw) and
+// words (w)
+var wordSorts = map[string]keysetSort{
+	WordSortCreatedAt:      {expr: "uw.created_at", cast: "timestamptz"},
+	WordSortWord:           {expr: "lower(w.word)", cast: "text"},
+	WordSortNextReviewAt:   {expr: "COALESCE(uw.next_review_at, 'infinity')", cast: "timestamptz"},
+	WordSortLastReviewedAt: {expr: "COALESCE(uw.last_reviewed_at, '-infinity')", cast: "timestamptz"},
+	WordSortEase:           {expr: "COALESCE(uw.easiness_factor, 2.5)", cast: "numeric"},
+	WordSortLapses:         {expr: "uw.lapses", cast: "integer"},
+	WordSortMastery:        {expr: "COALESCE(uw.mastery_level, 0)", cast: "integer"},
+}
+
+// WordListQuery selects one page of a user's words
+type WordListQuery struct {
+	Filter WordFilter
+	// Search is a word search query such as `ease<2 due:today is:leech`;
+	// see parseWordQuery for the syntax
+	Search string
+	Sort   string
+	// Desc orders from the largest sort key
+	Desc bool
+	PageRequest
+}
+
+// Validate checks the query, defaulting the sort and page size
+func (q *WordListQuery) Validate() error {
+	if q.Sort == "" {
+		q.Sort = WordSortCreatedAt
+	}
+	if _, ok := wordSorts[q.Sort]; !ok {
+		return fmt.Errorf("unknown sort: %s", q.Sort)
+	}
+	q.normalize()
+	if err := q.Filter.Validate(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(q.Search) != "" {
+		// Dates only change the values bound, not whether the query parses
+		if _, err := parseWordQuery(q.Search, DayBoundary{Timezone: "UTC"}, time.Now()); err != nil {
+			return err
+		}
+	}
+	if q.Cursor != "" {
+		if _, err := q.keyset().decode(q.Cursor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keyset returns the order the query lists words in
+func (q WordListQuery) keyset() keyset {
+	return keyset{name: q.Sort, sort: wordSorts[q.Sort], idColumn: "uw.id", desc: q.Desc}
+}
+
+// WordListItem is one of a user's words as listed
+type WordListItem struct {
+	UserWordID      uuid.UUID  `json:"user_word_id"`
+	WordID          uuid.UUID  `json:"word_id"`
+	Word            string     `json:"word"`
+	Phonetic        string     `json:"phonetic"`
+	Definitions     string     `json:"definitions"` // JSONB as string
+	GroupID         *uuid.UUID `json:"group_id"`
+	Tags            []string   `json:"tags"`
+	SourceURL       string     `json:"source_url"`
+	ContextSentence string     `json:"context_sentence"`
+	CardState       string     `json:"card_state"`
+	MasteryLevel    int        `json:"mastery_level"`
+	IsMastered      bool       `json:"is_mastered"`
+	IsLeech         bool       `json:"is_leech"`
+	EasinessFactor  float64    `json:"easiness_factor"`
+	Interval        int        `json:"interval"`
+	Lapses          int        `json:"lapses"`
+	NextReviewAt    *time.Time `json:"next_review_at"`
+	LastReviewedAt  *time.Time `json:"last_reviewed_at"`
+	SuspendedAt     *time.Time `json:"suspended_at"`
+	BuriedUntil     *time.Time `json:"buried_until"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// WordPage is one page of a user's words
+type WordPage struct {
+	Words []WordListItem `json:"words"`
+	// NextCursor continues the listing after this page; empty on the last page
+	NextCursor string `json:"next_cursor"`
+	// Total is the number of words matching the query, if requested
+	Total *int `json:"total,omitempty"`
+}
+
+// wordListColumns are the user_words (uw) and words (w) columns scanned by ListWords
+const wordListColumns = `
+	uw.id,
+	w.id,
+	w.word,
+	COALESCE(w.phonetic, ''),
+	w.definitions::text,
+	uw.group_id,
+	ARRAY(
+		SELECT t.name FROM user_word_tags uwt
+		JOIN tags t ON uwt.tag_id = t.id
+		WHERE uwt.user_word_id = uw.id
+		ORDER BY lower(t.name)
+	),
+	COALESCE(uw.source_url, ''),
+	COALESCE(uw.context_sentence, ''),
+	uw.card_state,
+	COALESCE(uw.mastery_level, 0),
+	uw.is_mastered,
+	uw.is_leech,
+	COALESCE(uw.easiness_factor, 2.5),
+	COALESCE(uw.interval, 0),
+	uw.lapses,
+	uw.next_review_at,
+	uw.last_reviewed_at,
+	uw.suspended_at,
+	uw.buried_until,
+	uw.created_at
+`
+
+// ListWords returns one page of the user's words matching the query's
+// filter and search, in its sort order with ties broken by ID, and the
+// total number of matching words if requested. The query must have been
+// validated.
+func (r *UserWordRepository) ListWords(ctx context.Context, userID string, q WordListQuery) (*WordPage, error) {
+	where, args, err := q.Filter.conditions([]interface{}{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(q.Search) != "" {
+		boundary, err := getDayBoundary(ctx, r.db, userID)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := parseWordQuery(q.Search, boundary, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		var cond string
+		cond, args = expr.sql(args)
+		where += " AND " + cond
+	}
+
+	page := &WordPage{Words: []WordListItem{}}
+	if q.WithTotal {
+		// The filter and search refer to user_words only, so the count needs no join
+		var total int
+		countQuery := `SELECT COUNT(*) FROM user_words uw WHERE uw.user_id = $1 AND ` + where
+		if err := r.db.Pool.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to count words: %w", err)
+		}
+		page.Total = &total
+	}
+
+	order := q.keyset()
+	after, args, err := order.after(q.Cursor, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra word to tell whether there is a next page
+	args = append(args, q.Limit+1)
+	query := `
+		SELECT ` + wordListColumns + `, ` + order.keyColumn() + `
+		FROM user_words uw
+		JOIN words w ON w.id = uw.word_id
+		WHERE uw.user_id = $1
+		  AND ` + where + `
+		  AND ` + after + `
+		ORDER BY ` + order.orderBy() + `
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list words: %w", err)
+	}
+	defer rows.Close()
+
+	var lastKey string
+	for rows.Next() {
+		var (
+			item WordListItem
+			key  string
+		)
+		err := rows.Scan(
+			&item.UserWordID,
+			&item.WordID,
+			&item.Word,
+			&item.Phonetic,
+			&item.Definitions,
+			&item.GroupID,
+			&item.Tags,
+			&item.SourceURL,
+			&item.ContextSentence,
+			&item.CardState,
+			&item.MasteryLevel,
+			&item.IsMastered,
+			&item.IsLeech,
+			&item.EasinessFactor,
+			&item.Interval,
+			&item.Lapses,
+			&item.NextReviewAt,
+			&item.LastReviewedAt,
+			&item.SuspendedAt,
+			&item.BuriedUntil,
+			&item.CreatedAt,
+			&key,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan word: %w", err)
+		}
+
+		// The extra word only tells that the page ends at the previous one
+		if len(page.Words) == q.Limit {
+			page.NextCursor = order.cursor(lastKey, page.Words[len(page.Words)-1].UserWordID)
+			break
+		}
+		page.Words = append(page.Words, item)
+		lastKey = key
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %w", err)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidWordQuery is returned for word search queries that do not parse
+var ErrInvalidWordQuery = errors.New("invalid word query")
+
+// maxQueryDays bounds the day counts of due:, added: and reviewed: terms
+const maxQueryDays = 3650
+
+// wordQueryFields maps the numeric fields of word queries to their columns
+// on user_words (uw)
+var wordQueryFields = map[string]string{
+	"ease":     "COALESCE(uw.easiness_factor, 2.5)",
+	"interval": "COALESCE(uw.interval, 0)",
+	"reps":     "COALESCE(uw.repetitions, 0)",
+	"lapses":   "uw.lapses",
+	"mastery":  "COALESCE(uw.mastery_level, 0)",
+}
+
+// wordQueryOperators maps the comparison operators of word queries to SQL,
+// longest first so that <= is not read as <
+var wordQueryOperators = []struct {
+	op  string
+	sql string
+}{
+	{"<=", "<="},
+	{">=", ">="},
+	{"!=", "<>"},
+	{"<", "<"},
+	{">", ">"},
+	{"=", "="},
+}
+
+// wordQueryStates are the is: values of word queries: every word status
+// plus words outside any group
+var wordQueryStates = func() map[string]string {
+	states := map[string]string{"ungrouped": "uw.group_id IS NULL"}
+	for status, cond := range wordStatusConditions {
+		states[status] = cond
+	}
+	return states
+}()
+
+// likeEscaper escapes LIKE wildcards so that they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// wordCondition is a word query term: a whitelisted condition on
+// user_words (uw) with a %s for the placeholder of each value
+type wordCondition struct {
+	format string
+	values []interface{}
+}
+
+func (c wordCondition) sql(args []interface{}) (string, []interface{}) {
+	placeholders := make([]interface{}, len(c.values))
+	for i, value := range c.values {
+		args = append(args, value)
+		placeholders[i] = fmt.Sprintf("$%d", len(args))
+	}
+	return "(" + fmt.Sprintf(c.format, placeholders...) + ")", args
+}
+
+// wordTermParser parses the terms of word search queries such as
+// `ease<2 due:today added:7d group:"Novel" is:leech word:pre*`. Dates in
+// terms are study days of boundary relative to now.
+type wordTermParser struct {
+	boundary DayBoundary
+	now      time.Time
+}
+
+// parseWordQuery parses a word search query. Terms are ANDed unless joined
+// with OR, and may be negated with NOT or a leading -:
+//
+//	word:pre*       the word matches a pattern, * matching any characters
+//	pre             the word contains the text
+//	tag:name        the word is tagged with name
+//	group:name      the word is in the group called name
+//	is:state        the word has a status, or is:ungrouped
+//	due:today       the word is due by the end of today; also due:overdue
+//	                and due:Nd, due within the next N study days
+//	added:7d        the word was added in the last N study days; also added:today
+//	reviewed:7d     the word was reviewed in the last N study days
+//	ease<2          compares ease, interval, reps, lapses or mastery with
+//	                <, <=, >, >=, = or !=
+func parseWordQuery(query string, boundary DayBoundary, now time.Time) (queryExpr, error) {
+	p := wordTermParser{boundary: boundary, now: now}
+	return parseQuery(query, p.parseTerm, ErrInvalidWordQuery)
+}
+
+func (p wordTermParser) parseTerm(text string) (queryExpr, error) {
+	if len(text) > 1 && text[0] == '-' {
+		expr, err := p.parseTerm(text[1:])
+		if err != nil {
+			return nil, err
+		}
+		return queryNot{expr: expr}, nil
+	}
+
+	colon := strings.Index(text, ":")
+	if op := strings.IndexAny(text, "<>=!"); op > 0 && (colon < 0 || op < colon) {
+		if _, ok := wordQueryFields[strings.ToLower(text[:op])]; ok {
+			return p.parseComparison(text, op)
+		}
+	}
+	if colon < 0 {
+		// A bare term matches words containing it
+		return wordPattern("*" + text + "*")
+	}
+
+	field, value := strings.ToLower(text[:colon]), text[colon+1:]
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty value for %s", ErrInvalidWordQuery, field)
+	}
+
+	switch field {
+	case "word":
+		return wordPattern(value)
+	case "tag":
+		if normalizeTagName(value) == "" {
+			return nil, fmt.Errorf("%w: empty tag name", ErrInvalidWordQuery)
+		}
+		return tagTerm{name: value}, nil
+	case "group":
+		return wordCondition{
+			format: `uw.group_id IN (SELECT g.id FROM groups g WHERE g.user_id = uw.user_id AND lower(g.name) = %s)`,
+			values: []interface{}{strings.ToLower(strings.TrimSpace(value))},
+		}, nil
+	case "is":
+		cond, ok := wordQueryStates[strings.ToLower(value)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown state %q", ErrInvalidWordQuery, value)
+		}
+		return wordCondition{format: cond}, nil
+	case "due":
+		return p.parseDue(value)
+	case "added":
+		return p.parseSince("uw.created_at", field, value)
+	case "reviewed":
+		return p.parseSince("uw.last_reviewed_at", field, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidWordQuery, field)
+	}
+}
+
+// parseComparison parses a numeric comparison such as ease<2 whose
+// operator starts at op
+func (p wordTermParser) parseComparison(text string, op int) (queryExpr, error) {
+	field := strings.ToLower(text[:op])
+	column := wordQueryFields[field]
+
+	rest := text[op:]
+	for _, operator := range wordQueryOperators {
+		value, ok := strings.CutPrefix(rest, operator.op)
+		if !ok {
+			continue
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s must be compared with a number, got %q", ErrInvalidWordQuery, field, value)
+		}
+		return wordCondition{
+			format: column + " " + operator.sql + " %s",
+			values: []interface{}{number},
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator in %q", ErrInvalidWordQuery, text)
+}
+
+// parseDue parses the value of a due: term
+func (p wordTermParser) parseDue(value string) (queryExpr, error) {
+	dayStart, dayEnd := p.boundary.Today(p.now)
+
+	var before time.Time
+	switch strings.ToLower(value) {
+	case "today":
+		before = dayEnd
+	case "overdue":
+		before = dayStart
+	default:
+		days, err := parseQueryDays("due", value)
+		if err != nil {
+			return nil, err
+		}
+		before = p.boundary.DayStart(p.boundary.StudyDate(p.now).AddDate(0, 0, days))
+	}
+
+	return wordCondition{
+		format: "uw.card_state <> 'new' AND uw.next_review_at < %s AND " + reviewableWordSQL,
+		values: []interface{}{before},
+	}, nil
+}
+
+// parseSince parses the value of a term matching words whose column falls
+// within the last N study days, today being the first
+func (p wordTermParser) parseSince(column, field, value string) (queryExpr, error) {
+	days := 1
+	if !strings.EqualFold(value, "today") {
+		var err error
+		if days, err = parseQueryDays(field, value); err != nil {
+			return nil, err
+		}
+	}
+
+	since := p.boundary.DayStart(p.boundary.StudyDate(p.now).AddDate(0, 0, 1-days))
+	return wordCondition{
+		format: column + " >= %s",
+		values: []interface{}{since},
+	}, nil
+}
+
+// parseQueryDays parses a day count such as 7d
+func parseQueryDays(field, value string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(value), "d"))
+	if err != nil || !strings.HasSuffix(strings.ToLower(value), "d") || days < 1 || days > maxQueryDays {
+		return 0, fmt.Errorf("%w: %s must be today or a number of days such as 7d, got %q", ErrInvalidWordQuery, field, value)
+	}
+	return days, nil
+}
+
+// wordPattern matches words against pattern ignoring case, * matching any
+// characters
+func wordPattern(pattern string) (queryExpr, error) {
+	if strings.Trim(pattern, "*") == "" {
+		return nil, fmt.Errorf("%w: empty word pattern", ErrInvalidWordQuery)
+	}
+	like := strings.ReplaceAll(likeEscaper.Replace(strings.ToLower(pattern)), "*", "%")
+	return wordCondition{
+		format: `EXISTS (SELECT 1 FROM words w WHERE w.id = uw.word_id AND lower(w.word) LIKE %s)`,
+		values: []interface{}{like},
+	}, nil
+}
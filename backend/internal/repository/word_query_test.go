@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWordQuery(t *testing.T) {
+	// Study days in Tokyo start at 04:00, 19:00 UTC the day before
+	boundary := DayBoundary{Timezone: "Asia/Tokyo", RolloverHour: 4}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	dayStart := time.Date(2026, 10, 15, 19, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	const (
+		due      = "(uw.card_state <> 'new' AND uw.next_review_at < $2 AND " + reviewableWordSQL + ")"
+		inGroup  = "(uw.group_id IN (SELECT g.id FROM groups g WHERE g.user_id = uw.user_id AND lower(g.name) = $2))"
+		wordLike = "(EXISTS (SELECT 1 FROM words w WHERE w.id = uw.word_id AND lower(w.word) LIKE $2))"
+	)
+
+	tests := []struct {
+		name     string
+		query    string
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "comparison",
+			query:    "ease<2",
+			wantSQL:  "(COALESCE(uw.easiness_factor, 2.5) < $2)",
+			wantArgs: []interface{}{"user", 2.0},
+		},
+		{
+			name:     "longest operator wins",
+			query:    "lapses>=3",
+			wantSQL:  "(uw.lapses >= $2)",
+			wantArgs: []interface{}{"user", 3.0},
+		},
+		{
+			name:     "not equal",
+			query:    "Interval!=1.5",
+			wantSQL:  "(COALESCE(uw.interval, 0) <> $2)",
+			wantArgs: []interface{}{"user", 1.5},
+		},
+		{
+			name:     "implicit AND of different fields",
+			query:    "reps>2 is:leech",
+			wantSQL:  "((COALESCE(uw.repetitions, 0) > $2) AND (uw.is_leech))",
+			wantArgs: []interface{}{"user", 2.0},
+		},
+		{
+			name:     "OR precedence",
+			query:    "is:new OR mastery<=1 is:leech",
+			wantSQL:  "((uw.card_state = 'new') OR ((COALESCE(uw.mastery_level, 0) <= $2) AND (uw.is_leech)))",
+			wantArgs: []interface{}{"user", 1.0},
+		},
+		{
+			name:     "bare term contains",
+			query:    "pre",
+			wantSQL:  wordLike,
+			wantArgs: []interface{}{"user", "%pre%"},
+		},
+		{
+			name:     "word pattern escapes LIKE wildcards",
+			query:    "word:100%_*",
+			wantSQL:  wordLike,
+			wantArgs: []interface{}{"user", `100\%\_%`},
+		},
+		{
+			name:     "quoted group",
+			query:    `group:"Short Stories"`,
+			wantSQL:  inGroup,
+			wantArgs: []interface{}{"user", "short stories"},
+		},
+		{
+			name:     "negated group matches ungrouped words",
+			query:    "-group:Novel",
+			wantSQL:  "NOT COALESCE(" + inGroup + ", FALSE)",
+			wantArgs: []interface{}{"user", "novel"},
+		},
+		{
+			name:     "negated buried matches never buried words",
+			query:    "-is:buried",
+			wantSQL:  "NOT COALESCE((uw.buried_until IS NOT NULL AND uw.buried_until > NOW()), FALSE)",
+			wantArgs: []interface{}{"user"},
+		},
+		{
+			name:     "NOT keyword",
+			query:    "NOT is:ungrouped",
+			wantSQL:  "NOT COALESCE((uw.group_id IS NULL), FALSE)",
+			wantArgs: []interface{}{"user"},
+		},
+		{
+			name:     "due today",
+			query:    "due:today",
+			wantSQL:  due,
+			wantArgs: []interface{}{"user", dayStart.Add(day)},
+		},
+		{
+			name:     "overdue",
+			query:    "due:overdue",
+			wantSQL:  due,
+			wantArgs: []interface{}{"user", dayStart},
+		},
+		{
+			name:     "due within days",
+			query:    "due:3d",
+			wantSQL:  due,
+			wantArgs: []interface{}{"user", dayStart.Add(3 * day)},
+		},
+		{
+			name:     "added today",
+			query:    "added:today",
+			wantSQL:  "(uw.created_at >= $2)",
+			wantArgs: []interface{}{"user", dayStart},
+		},
+		{
+			name:     "negated reviewed matches never reviewed words",
+			query:    "-reviewed:7d",
+			wantSQL:  "NOT COALESCE((uw.last_reviewed_at >= $2), FALSE)",
+			wantArgs: []interface{}{"user", dayStart.Add(-6 * day)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := parseWordQuery(tt.query, boundary, now)
+			if err != nil {
+				t.Fatalf("parseWordQuery(%q) error: %v", tt.query, err)
+			}
+			sql, args := expr.sql([]interface{}{"user"})
+			if sql != tt.wantSQL {
+				t.Errorf("sql = %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(normalizeTimes(args), normalizeTimes(tt.wantArgs)) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestParseWordQueryErrors(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr string
+	}{
+		{`word:"pre`, "unterminated quote"},
+		{"ease<abc", `ease must be compared with a number, got "abc"`},
+		{"ease<", `ease must be compared with a number, got ""`},
+		{"ease=>2", `ease must be compared with a number, got ">2"`},
+		{"color:red", `unknown field "color"`},
+		{"is:forgotten", `unknown state "forgotten"`},
+		{"due:0d", `due must be today or a number of days such as 7d, got "0d"`},
+		{"due:7", `got "7"`},
+		{"added:99999d", `got "99999d"`},
+		{"group:", "empty value for group"},
+		{"tag:#", "empty tag name"},
+		{"word:**", "empty word pattern"},
+	}
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := parseWordQuery(tt.query, DayBoundary{Timezone: "UTC"}, now)
+			if !errors.Is(err, ErrInvalidWordQuery) {
+				t.Fatalf("parseWordQuery(%q) error = %v, want %v", tt.query, err, ErrInvalidWordQuery)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// normalizeTimes converts the times among args to UTC so that they compare
+// by instant
+func normalizeTimes(args []interface{}) []interface{} {
+	normalized := make([]interface{}, len(args))
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			arg = t.UTC()
+		}
+		normalized[i] = arg
+	}
+	return normalized
+}
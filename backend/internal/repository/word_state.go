@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MatchWords returns the IDs of the user's words selected by filter
+func (r *ReviewRepository) MatchWords(ctx context.Context, userID string, filter WordFilter) ([]uuid.UUID, error) {
+	return matchWords(ctx, r.db.Pool, userID, filter)
+}
+
+// wordQuerier is the part of a pool or transaction used by matchWords
+type wordQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func matchWords(ctx context.Context, db wordQuerier, userID string, filter WordFilter) ([]uuid.UUID, error) {
+	where, args, err := filter.conditions([]interface{}{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT uw.id
+		FROM user_words uw
+		WHERE uw.user_id = $1
+		  AND ` + where
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query words: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan word id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating words: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SuspendWords stops the selected words from being reviewed until they are
+// unsuspended. It returns the number of words matched.
+func (r *ReviewRepository) SuspendWords(ctx context.Context, userID string, filter WordFilter) (int, error) {
+	return r.updateWords(ctx, userID, filter, `suspended_at = COALESCE(suspended_at, NOW())`)
+}
+
+// UnsuspendWords makes suspended words reviewable again
+func (r *ReviewRepository) UnsuspendWords(ctx context.Context, userID string, filter WordFilter) (int, error) {
+	return r.updateWords(ctx, userID, filter, `suspended_at = NULL`)
+}
+
+// BuryWords skips the selected words until the user's next study day starts
+func (r *ReviewRepository) BuryWords(ctx context.Context, userID string, filter WordFilter) (int, error) {
+	boundary, err := getDayBoundary(ctx, r.db, userID)
+	if err != nil {
+		return 0, err
+	}
+	_, dayEnd := boundary.Today(time.Now())
+
+	return r.updateWords(ctx, userID, filter, `buried_until = $3`, dayEnd)
+}
+
+// UnburyWords makes buried words reviewable again today
+func (r *ReviewRepository) UnburyWords(ctx context.Context, userID string, filter WordFilter) (int, error) {
+	return r.updateWords(ctx, userID, filter, `buried_until = NULL`)
+}
+
+// updateWords applies set to the words selected by filter in one transaction.
+// set may refer to extra arguments from $3.
+func (r *ReviewRepository) updateWords(ctx context.Context, userID string, filter WordFilter, set string, extra ...interface{}) (int, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Resolve the filter first, so that status filters are not affected by the update
+	ids, err := matchWords(ctx, tx, userID, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		UPDATE user_words
+		SET ` + set + `, updated_at = NOW()
+		WHERE user_id = $1 AND id = ANY($2)
+	`
+	args := append([]interface{}{userID, ids}, extra...)
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to update words: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(ids), nil
+}
+
+// ResetWords returns every card of the selected words to the new state and
+// clears their lapses and leech flag, lifting the suspension of words the
+// leech action suspended. Review history is kept.
+func (r *ReviewRepository) ResetWords(ctx context.Context, userID string, filter WordFilter) (int, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	ids, err := matchWords(ctx, tx, userID, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	cardsQuery := `
+		UPDATE review_cards
+		SET
+			easiness_factor = 2.5,
+			interval = 0,
+			repetitions = 0,
+			stability = 0,
+			difficulty = 0,
+			card_state = 'new',
+			learning_step = 0,
+			last_reviewed_at = NULL,
+			next_review_at = NULL,
+			lapses = 0,
+			updated_at = NOW()
+		WHERE user_id = $1 AND user_word_id = ANY($2)
+	`
+	if _, err := tx.Exec(ctx, cardsQuery, userID, ids); err != nil {
+		return 0, fmt.Errorf("failed to reset cards: %w", err)
+	}
+
+	// A suspension is the leech's when a review logged it at that time
+	wordsQuery := `
+		UPDATE user_words uw
+		SET
+			suspended_at = CASE
+				WHEN uw.is_leech AND EXISTS (
+					SELECT 1 FROM review_logs rl
+					WHERE rl.user_word_id = uw.id
+					  AND rl.leech_suspended
+					  AND rl.reviewed_at = uw.suspended_at
+				) THEN NULL
+				ELSE uw.suspended_at
+			END,
+			easiness_factor = 2.5,
+			interval = 0,
+			repetitions = 0,
+			stability = 0,
+			difficulty = 0,
+			card_state = 'new',
+			learning_step = 0,
+			last_reviewed_at = NULL,
+			next_review_at = NULL,
+			lapses = 0,
+			is_leech = FALSE,
+			mastery_level = 0,
+			is_mastered = FALSE,
+			buried_until = NULL,
+			updated_at = NOW()
+		WHERE uw.user_id = $1 AND uw.id = ANY($2)
+	`
+	if _, err := tx.Exec(ctx, wordsQuery, userID, ids); err != nil {
+		return 0, fmt.Errorf("failed to reset words: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(ids), nil
+}
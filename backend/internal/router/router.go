@@ -8,12 +8,17 @@ import (
 )
 
 type Router struct {
-	healthHandler    *handler.HealthHandler
-	authHandler      *handler.AuthHandler
-	wordsHandler     *handler.WordsHandler
-	dashboardHandler *handler.DashboardHandler
-	ocrHandler       *handler.OCRHandler
-	authMiddleware   *middleware.AuthMiddleware
+	healthHandler     *handler.HealthHandler
+	authHandler       *handler.AuthHandler
+	wordsHandler      *handler.WordsHandler
+	dashboardHandler  *handler.DashboardHandler
+	reviewHandler     *handler.ReviewHandler
+	statsHandler      *handler.StatsHandler
+	groupHandler      *handler.GroupHandler
+	tagHandler        *handler.TagHandler
+	dictionaryHandler *handler.DictionaryHandler
+	ocrHandler        *handler.OCRHandler
+	authMiddleware    *middleware.AuthMiddleware
 }
 
 func New(
@@ -21,16 +26,26 @@ func New(
 	authHandler *handler.AuthHandler,
 	wordsHandler *handler.WordsHandler,
 	dashboardHandler *handler.DashboardHandler,
+	reviewHandler *handler.ReviewHandler,
+	statsHandler *handler.StatsHandler,
+	groupHandler *handler.GroupHandler,
+	tagHandler *handler.TagHandler,
+	dictionaryHandler *handler.DictionaryHandler,
 	ocrHandler *handler.OCRHandler,
 	authMiddleware *middleware.AuthMiddleware,
 ) *Router {
 	return &Router{
-		healthHandler:    healthHandler,
-		authHandler:      authHandler,
-		wordsHandler:     wordsHandler,
-		dashboardHandler: dashboardHandler,
-		ocrHandler:       ocrHandler,
-		authMiddleware:   authMiddleware,
+		healthHandler:     healthHandler,
+		authHandler:       authHandler,
+		wordsHandler:      wordsHandler,
+		dashboardHandler:  dashboardHandler,
+		reviewHandler:     reviewHandler,
+		statsHandler:      statsHandler,
+		groupHandler:      groupHandler,
+		tagHandler:        tagHandler,
+		dictionaryHandler: dictionaryHandler,
+		ocrHandler:        ocrHandler,
+		authMiddleware:    authMiddleware,
 	}
 }
 
@@ -56,6 +71,7 @@ func (rt *Router) Setup() *chi.Mux {
 
 			// Auth
 			r.Get("/auth/profile", rt.authHandler.GetProfile)
+			r.Put("/auth/profile", rt.authHandler.UpdateProfile)
 
 			// Dashboard
 			r.Get("/dashboard", rt.dashboardHandler.GetDashboard)
@@ -64,6 +80,53 @@ func (rt *Router) Setup() *chi.Mux {
 			r.Get("/words", rt.wordsHandler.List)
 			r.Get("/words/{id}", rt.wordsHandler.Get)
 
+			// Review
+			r.Get("/review/due", rt.reviewHandler.GetDueReviews)
+			r.Post("/review/submit", rt.reviewHandler.SubmitReview)
+			r.Post("/review/grade", rt.reviewHandler.GradeTypedAnswer)
+			r.Post("/review/undo", rt.reviewHandler.UndoReview)
+			r.Get("/review/stats", rt.reviewHandler.GetReviewStats)
+			r.Get("/review/history", rt.reviewHandler.GetReviewHistory)
+			r.Get("/review/leeches", rt.reviewHandler.GetLeeches)
+			r.Patch("/review/leeches/{id}", rt.reviewHandler.UpdateWordNotes)
+			r.Post("/review/words/bulk", rt.reviewHandler.BulkWordAction)
+			r.Post("/review/words/{id}/{action}", rt.reviewHandler.ApplyWordAction)
+			r.Get("/review/parameters", rt.reviewHandler.GetParameters)
+			r.Post("/review/parameters/optimize", rt.reviewHandler.OptimizeParameters)
+			r.Get("/review/retention/projection", rt.reviewHandler.GetRetentionProjection)
+			r.Get("/review/forecast", rt.reviewHandler.GetForecast)
+			r.Post("/review/sessions", rt.reviewHandler.CreateSession)
+			r.Get("/review/sessions/{id}/next", rt.reviewHandler.GetNextCard)
+			r.Post("/review/sessions/{id}/answers", rt.reviewHandler.SubmitSessionAnswer)
+			r.Get("/review/sessions/{id}/summary", rt.reviewHandler.GetSessionSummary)
+
+			// Stats
+			r.Get("/stats/retention", rt.statsHandler.GetRetention)
+			r.Get("/stats/calendar", rt.statsHandler.GetCalendar)
+
+			// Groups
+			r.Get("/groups", rt.groupHandler.ListGroups)
+			r.Post("/groups", rt.groupHandler.CreateGroup)
+			r.Put("/groups/order", rt.groupHandler.ReorderGroups)
+			r.Post("/groups/move", rt.groupHandler.MoveWords)
+			r.Get("/groups/{id}", rt.groupHandler.GetGroup)
+			r.Patch("/groups/{id}", rt.groupHandler.UpdateGroup)
+			r.Delete("/groups/{id}", rt.groupHandler.DeleteGroup)
+			r.Put("/groups/{id}/words/{word_id}", rt.groupHandler.AddWord)
+			r.Delete("/groups/{id}/words/{word_id}", rt.groupHandler.RemoveWord)
+
+			// Tags
+			r.Get("/tags", rt.tagHandler.ListTags)
+			r.Post("/tags", rt.tagHandler.CreateTag)
+			r.Get("/tags/autocomplete", rt.tagHandler.AutocompleteTags)
+			r.Post("/tags/attach", rt.tagHandler.AttachTags)
+			r.Post("/tags/detach", rt.tagHandler.DetachTags)
+			r.Patch("/tags/{id}", rt.tagHandler.UpdateTag)
+			r.Delete("/tags/{id}", rt.tagHandler.DeleteTag)
+
+			// Dictionary
+			r.Get("/dictionary/search", rt.dictionaryHandler.Search)
+
 			// OCR
 			r.Post("/ocr/analyze", rt.ocrHandler.AnalyzeImage)
 		})
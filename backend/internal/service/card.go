@@ -0,0 +1,123 @@
+package service
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Card types: each collected word is reviewed through one card per type,
+// each with its own scheduling state
+const (
+	CardTypeRecognition = "recognition" // word -> meaning
+	CardTypeRecall      = "recall"      // meaning -> word
+	CardTypeSpelling    = "spelling"    // meaning and pronunciation -> typed word
+	CardTypeCloze       = "cloze"       // context sentence with the word blanked -> word
+)
+
+// CardTypes lists every card type, from the easiest direction to the hardest
+var CardTypes = []string{CardTypeRecognition, CardTypeRecall, CardTypeSpelling, CardTypeCloze}
+
+// ClozeBlank replaces the word in a cloze card's sentence
+const ClozeBlank = "____"
+
+// CardFace is one side of a card; only the fields the side shows are set
+type CardFace struct {
+	Word        string          `json:"word,omitempty"`
+	Phonetic    string          `json:"phonetic,omitempty"`
+	Definitions json.RawMessage `json:"definitions,omitempty"`
+	// CustomDefinition is the user's own definition, shown wherever definitions are
+	CustomDefinition string `json:"custom_definition,omitempty"`
+	Sentence         string `json:"sentence,omitempty"`
+	Mnemonic         string `json:"mnemonic,omitempty"`
+}
+
+// CardPayload is what a client needs to render a card
+type CardPayload struct {
+	Front CardFace `json:"front"`
+	Back  CardFace `json:"back"`
+	// Typed is set for cards answered by typing the word rather than self-grading
+	Typed bool `json:"typed"`
+}
+
+// ReviewCard is a card with its scheduling state and render payload
+type ReviewCard struct {
+	repository.DueWord
+	Payload CardPayload `json:"payload"`
+}
+
+// NewReviewCard builds the payload of a card from its type and word
+func NewReviewCard(word repository.DueWord) ReviewCard {
+	var definitions json.RawMessage
+	if json.Valid([]byte(word.Definitions)) {
+		definitions = json.RawMessage(word.Definitions)
+	}
+
+	custom := word.CustomDefinition
+
+	var payload CardPayload
+	switch word.CardType {
+	case CardTypeRecall:
+		payload.Front = CardFace{Definitions: definitions, CustomDefinition: custom}
+		payload.Back = CardFace{Word: word.Word, Phonetic: word.Phonetic}
+	case CardTypeSpelling:
+		payload.Front = CardFace{Definitions: definitions, CustomDefinition: custom, Phonetic: word.Phonetic}
+		payload.Back = CardFace{Word: word.Word}
+		payload.Typed = true
+	case CardTypeCloze:
+		payload.Front = CardFace{Sentence: clozeSentence(word.ContextSentence, word.Word)}
+		payload.Back = CardFace{Word: word.Word, Definitions: definitions, CustomDefinition: custom, Sentence: word.ContextSentence}
+		payload.Typed = true
+	default:
+		payload.Front = CardFace{Word: word.Word, Phonetic: word.Phonetic}
+		payload.Back = CardFace{Definitions: definitions, CustomDefinition: custom}
+	}
+	// The mnemonic is a memory aid for the answer, so it is shown with it
+	payload.Back.Mnemonic = word.Mnemonic
+
+	return ReviewCard{DueWord: word, Payload: payload}
+}
+
+// newReviewCards builds the payloads of a list of cards
+func newReviewCards(words []repository.DueWord) []ReviewCard {
+	cards := make([]ReviewCard, 0, len(words))
+	for _, word := range words {
+		cards = append(cards, NewReviewCard(word))
+	}
+	return cards
+}
+
+// clozeSentence blanks the word, including inflected endings such as
+// "-ed" or "-s", out of sentence. Whole-word matches are preferred over
+// matches inside longer words.
+func clozeSentence(sentence, word string) string {
+	if word == "" {
+		return sentence
+	}
+
+	quoted := regexp.QuoteMeta(word)
+	wholeWord := regexp.MustCompile(`(?i)\b` + quoted + `\w*`)
+	if wholeWord.MatchString(sentence) {
+		return wholeWord.ReplaceAllString(sentence, ClozeBlank)
+	}
+
+	return regexp.MustCompile(`(?i)`+quoted).ReplaceAllString(sentence, ClozeBlank)
+}
+
+// withoutSiblings keeps the first card of each word, skipping words already
+// in seen, so that one answer does not give away another card of the same
+// word shown shortly after. seen is updated with the kept words.
+func withoutSiblings(words []repository.DueWord, seen map[uuid.UUID]bool) []repository.DueWord {
+	kept := words[:0:0]
+	for _, word := range words {
+		if seen[word.UserWordID] {
+			continue
+		}
+		seen[word.UserWordID] = true
+		kept = append(kept, word)
+	}
+	return kept
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"vocabweb/internal/repository"
+)
+
+// DefaultDashboardTTL is how long a user's dashboard is served from the cache.
+// Reviews and new words invalidate it straight away; the TTL bounds how stale
+// it gets through other changes and across the user's day rollover.
+const DefaultDashboardTTL = 5 * time.Minute
+
+// maxCachedDashboards is the cache size above which expired entries are
+// swept on the next store
+const maxCachedDashboards = 10000
+
+// CachedDashboard is a user's dashboard with its JSON encoding and the ETag
+// identifying that encoding
+type CachedDashboard struct {
+	Dashboard *repository.Dashboard
+	JSON      []byte
+	ETag      string
+}
+
+type dashboardEntry struct {
+	dashboard *CachedDashboard
+	expires   time.Time
+}
+
+// DashboardService serves users' dashboards from an in-memory per-user cache
+type DashboardService struct {
+	statsRepo *repository.StatsRepository
+	ttl       time.Duration
+	clock     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]dashboardEntry
+	// invalidations counts Invalidate calls, so that a dashboard computed
+	// across one is not cached
+	invalidations uint64
+}
+
+// NewDashboardService creates a dashboard service caching each dashboard for ttl
+func NewDashboardService(statsRepo *repository.StatsRepository, ttl time.Duration) *DashboardService {
+	if ttl <= 0 {
+		ttl = DefaultDashboardTTL
+	}
+	return &DashboardService{
+		statsRepo: statsRepo,
+		ttl:       ttl,
+		clock:     time.Now,
+		entries:   make(map[string]dashboardEntry),
+	}
+}
+
+// GetDashboard returns the user's dashboard from the cache, computing it when
+// it is missing or expired
+func (s *DashboardService) GetDashboard(ctx context.Context, userID string) (*CachedDashboard, error) {
+	now := s.clock()
+
+	s.mu.Lock()
+	entry, ok := s.entries[userID]
+	invalidations := s.invalidations
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.dashboard, nil
+	}
+
+	dashboard, err := s.statsRepo.GetDashboard(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(dashboard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	cached := &CachedDashboard{
+		Dashboard: dashboard,
+		JSON:      body,
+		ETag:      `"` + hex.EncodeToString(sum[:16]) + `"`,
+	}
+
+	s.mu.Lock()
+	if s.invalidations == invalidations {
+		if len(s.entries) >= maxCachedDashboards {
+			s.sweep(now)
+		}
+		s.entries[userID] = dashboardEntry{dashboard: cached, expires: now.Add(s.ttl)}
+	}
+	s.mu.Unlock()
+
+	return cached, nil
+}
+
+// Invalidate drops the user's cached dashboard. It is safe to call on a nil
+// service.
+func (s *DashboardService) Invalidate(userID string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.entries, userID)
+	s.invalidations++
+	s.mu.Unlock()
+}
+
+// sweep drops expired entries; s.mu must be held
+func (s *DashboardService) sweep(now time.Time) {
+	for userID, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, userID)
+		}
+	}
+}
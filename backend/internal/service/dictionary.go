@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"vocabweb/internal/repository"
+)
+
+// Dictionary search limits, in words
+const (
+	DefaultDictionaryResults = 20
+	MaxDictionaryResults     = 50
+)
+
+// MaxDictionaryQueryLength bounds search queries, in characters
+const MaxDictionaryQueryLength = 100
+
+// Dictionary search modes
+const (
+	// DictionarySearchFull matches words, typos and definitions
+	DictionarySearchFull = "full"
+	// DictionarySearchAutocomplete only completes word prefixes, for search-as-you-type
+	DictionarySearchAutocomplete = "autocomplete"
+)
+
+// ErrInvalidDictionaryQuery is returned for empty or overlong search queries
+var ErrInvalidDictionaryQuery = errors.New("invalid dictionary query")
+
+// DictionaryService searches the global dictionary
+type DictionaryService struct {
+	wordRepo *repository.WordRepository
+}
+
+// NewDictionaryService creates a new dictionary service instance
+func NewDictionaryService(wordRepo *repository.WordRepository) *DictionaryService {
+	return &DictionaryService{wordRepo: wordRepo}
+}
+
+// DictionarySearchRequest is a dictionary search
+type DictionarySearchRequest struct {
+	Query string
+	Mode  string
+	Limit int
+}
+
+// Validate checks the query and mode, defaulting the mode and limit
+func (req *DictionarySearchRequest) Validate() error {
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		return fmt.Errorf("%w: q is required", ErrInvalidDictionaryQuery)
+	}
+	if utf8.RuneCountInString(req.Query) > MaxDictionaryQueryLength {
+		return fmt.Errorf("%w: q must be at most %d characters", ErrInvalidDictionaryQuery, MaxDictionaryQueryLength)
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = DictionarySearchFull
+	case DictionarySearchFull, DictionarySearchAutocomplete:
+	default:
+		return fmt.Errorf("mode must be %s or %s", DictionarySearchFull, DictionarySearchAutocomplete)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = DefaultDictionaryResults
+	}
+	if req.Limit > MaxDictionaryResults {
+		req.Limit = MaxDictionaryResults
+	}
+	return nil
+}
+
+// Search returns the dictionary words best matching the request, marking
+// those the user has collected
+func (s *DictionaryService) Search(ctx context.Context, userID string, req DictionarySearchRequest) ([]repository.DictionaryEntry, error) {
+	return s.wordRepo.SearchWords(ctx, userID, req.Query, req.Limit, req.Mode == DictionarySearchAutocomplete)
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Forecast horizon limits in days
+const (
+	DefaultForecastDays = 30
+	MaxForecastDays     = 365
+)
+
+// GroupForecast is the number of cards of one group due on a day; cards of
+// ungrouped words have no GroupID
+type GroupForecast struct {
+	GroupID *uuid.UUID `json:"group_id"`
+	Name    string     `json:"name"`
+	Cards   int        `json:"cards"`
+}
+
+// ForecastDay is the review load of one study day
+type ForecastDay struct {
+	Date string `json:"date"`
+	// Due counts the cards already scheduled for the day, split into young
+	// and mature cards and by group
+	Due    int             `json:"due"`
+	Young  int             `json:"young"`
+	Mature int             `json:"mature"`
+	Groups []GroupForecast `json:"groups"`
+	// ProjectedReviews is the simulated number of reviews on the day,
+	// including repeats of forgotten cards and the new cards introduced
+	// that day, of which there are ProjectedNew
+	ProjectedReviews float64 `json:"projected_reviews"`
+	ProjectedNew     int     `json:"projected_new"`
+}
+
+// ReviewForecast is the expected review load of the next study days
+type ReviewForecast struct {
+	Days int `json:"days"`
+	// MatureInterval is the interval in days from which a card is mature
+	MatureInterval    int           `json:"mature_interval"`
+	NewCardsPerDay    int           `json:"new_cards_per_day"`
+	NewCardsAvailable int           `json:"new_cards_available"`
+	DesiredRetention  float64       `json:"desired_retention"`
+	Forecast          []ForecastDay `json:"forecast"`
+}
+
+// ForecastReviews returns the cards coming due on each of the next study
+// days, with a simulated projection of the actual review load: failed cards
+// coming back sooner, passed cards moving out, and new cards introduced at
+// the user's daily limit until none are left. Day 0 is today and includes
+// overdue cards.
+func (s *ReviewService) ForecastReviews(ctx context.Context, userID string, days int) (*ReviewForecast, error) {
+	if days <= 0 {
+		days = DefaultForecastDays
+	}
+	if days > MaxForecastDays {
+		days = MaxForecastDays
+	}
+
+	due, err := s.reviewRepo.GetDueForecast(ctx, userID, days)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+	scheduler, err := NewUserScheduler(*settings)
+	if err != nil {
+		return nil, err
+	}
+	retention := settings.DesiredRetention
+	if retention <= 0 {
+		retention = DefaultDesiredRetention
+	}
+
+	limits, err := s.reviewRepo.GetStudyLimits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.reviewRepo.GetTodayStudyCounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get today's study counts: %w", err)
+	}
+	available, err := s.reviewRepo.CountNewCards(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	words, err := s.reviewRepo.GetScheduledWords(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled words: %w", err)
+	}
+
+	forecast := &ReviewForecast{
+		Days:              days,
+		MatureInterval:    repository.MatureInterval,
+		NewCardsPerDay:    limits.NewCardsPerDay,
+		NewCardsAvailable: available,
+		DesiredRetention:  retention,
+		Forecast:          make([]ForecastDay, days),
+	}
+
+	for day := range forecast.Forecast {
+		forecast.Forecast[day] = ForecastDay{
+			Date:   due.Today.AddDate(0, 0, day).Format("2006-01-02"),
+			Groups: []GroupForecast{},
+		}
+	}
+	for _, count := range due.Counts {
+		day := &forecast.Forecast[count.Day]
+		day.Due += count.Cards
+		if count.Mature {
+			day.Mature += count.Cards
+		} else {
+			day.Young += count.Cards
+		}
+		addGroupForecast(day, count)
+	}
+
+	// New cards are introduced at the daily limit, with today's introductions counted
+	remaining := available
+	newCards := make([]int, days)
+	for day := range newCards {
+		quota := limits.NewCardsPerDay
+		if day == 0 {
+			quota -= counts.Introduced
+		}
+		if quota < 0 {
+			quota = 0
+		}
+		newCards[day] = minInt(quota, remaining)
+		remaining -= newCards[day]
+		forecast.Forecast[day].ProjectedNew = newCards[day]
+	}
+
+	projected := simulateForecast(scheduler, words, newCards, retention, s.clock(), due.TodayStart)
+	for day, reviews := range projected {
+		forecast.Forecast[day].ProjectedReviews = reviews
+	}
+
+	return forecast, nil
+}
+
+// addGroupForecast adds a due count to its group's entry of the day; counts
+// arrive ordered by group so only the last entry needs checking
+func addGroupForecast(day *ForecastDay, count repository.DueCount) {
+	if n := len(day.Groups); n > 0 && sameGroup(day.Groups[n-1].GroupID, count.GroupID) {
+		day.Groups[n-1].Cards += count.Cards
+		return
+	}
+	day.Groups = append(day.Groups, GroupForecast{GroupID: count.GroupID, Name: count.GroupName, Cards: count.Cards})
+}
+
+func sameGroup(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// simulateForecast averages projectionRuns simulations of the user's studied
+// cards from their scheduled due dates, and of newCards[day] new cards first
+// studied on each day, and returns the reviews falling on each study day.
+// Study days are counted in 24 hour steps from todayStart.
+func simulateForecast(scheduler Scheduler, words []repository.DueWord, newCards []int, retention float64, now, todayStart time.Time) []float64 {
+	days := len(newCards)
+	horizon := addDays(todayStart, days)
+	daily := make([]float64, days)
+	count := func(at time.Time, recalled bool) {
+		if day := int(at.Sub(todayStart).Hours() / 24); day >= 0 && day < days {
+			daily[day]++
+		}
+	}
+
+	for run := 0; run < projectionRuns; run++ {
+		rng := rand.New(rand.NewSource(int64(run + 1)))
+
+		for _, word := range words {
+			due := now
+			if word.NextReviewAt != nil && word.NextReviewAt.After(now) {
+				due = *word.NextReviewAt
+			}
+			simulateCard(scheduler, cardStateFromDueWord(word), due, horizon, retention, rng, count)
+		}
+
+		for day, n := range newCards {
+			introduced := addDays(todayStart, day)
+			if introduced.Before(now) {
+				introduced = now
+			}
+			for i := 0; i < n; i++ {
+				simulateCard(scheduler, CardState{EasinessFactor: 2.5, State: CardStateNew}, introduced, horizon, retention, rng, count)
+			}
+		}
+	}
+
+	for i := range daily {
+		daily[i] /= float64(projectionRuns)
+	}
+
+	return daily
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"math"
+	"time"
+)
+
+// FSRS forgetting curve constants: R(t, S) = (1 + fsrsFactor * t / S) ^ fsrsDecay
+const (
+	fsrsDecay  = -0.5
+	fsrsFactor = 19.0 / 81.0
+)
+
+// DefaultFSRSWeights are the published FSRS v4.5 default model weights
+var DefaultFSRSWeights = [17]float64{
+	0.4872, 1.4003, 3.7145, 13.8206, 5.1618, 1.2298, 0.8975, 0.031,
+	1.6474, 0.1367, 1.0461, 2.1072, 0.0793, 0.3246, 1.587, 0.2272, 2.8755,
+}
+
+// FSRS ratings
+const (
+	fsrsAgain = 1
+	fsrsHard  = 2
+	fsrsGood  = 3
+	fsrsEasy  = 4
+)
+
+// FSRSScheduler implements the Free Spaced Repetition Scheduler (FSRS v4.5),
+// which models each word by its memory stability (days until recall
+// probability drops to 90%), difficulty (1-10) and retrievability.
+type FSRSScheduler struct {
+	Weights          [17]float64
+	RequestRetention float64
+	MaximumInterval  int
+}
+
+// NewFSRSScheduler creates an FSRS scheduler with the default weights
+func NewFSRSScheduler() *FSRSScheduler {
+	return &FSRSScheduler{
+		Weights:          DefaultFSRSWeights,
+		RequestRetention: DefaultDesiredRetention,
+		MaximumInterval:  36500,
+	}
+}
+
+// Name returns the scheduler name stored on profiles and review logs
+func (s *FSRSScheduler) Name() string {
+	return SchedulerFSRS
+}
+
+// Schedule computes the next memory state and interval for the card.
+// Words that only have SM-2 history are seeded from their SM-2 state.
+func (s *FSRSScheduler) Schedule(state CardState, quality int, now time.Time) ScheduleResult {
+	quality = clampQuality(quality)
+	rating := fsrsRating(quality)
+
+	var stability, difficulty float64
+	if state.LastReviewedAt == nil && state.Stability <= 0 {
+		// First review of a new word
+		stability = s.initStability(rating)
+		difficulty = s.initDifficulty(rating)
+	} else {
+		lastStability, lastDifficulty := state.Stability, state.Difficulty
+		if lastStability <= 0 {
+			lastStability = math.Max(float64(state.Interval), 0.5)
+		}
+		if lastDifficulty <= 0 {
+			lastDifficulty = difficultyFromEasiness(state.EasinessFactor)
+		}
+
+		elapsed := float64(state.Interval)
+		if state.LastReviewedAt != nil {
+			elapsed = math.Max(0, now.Sub(*state.LastReviewedAt).Hours()/24)
+		}
+		retrievability := FSRSRetrievability(elapsed, lastStability)
+
+		difficulty = s.nextDifficulty(lastDifficulty, rating)
+		if rating == fsrsAgain {
+			stability = s.forgetStability(lastDifficulty, lastStability, retrievability)
+		} else {
+			stability = s.recallStability(lastDifficulty, lastStability, retrievability, rating)
+		}
+	}
+
+	interval := s.nextInterval(stability)
+	repetitions := 0
+	if quality >= 3 {
+		repetitions = state.Repetitions + 1
+	}
+
+	ef := state.EasinessFactor
+	if ef == 0 {
+		ef = 2.5
+	}
+
+	return ScheduleResult{
+		EasinessFactor: nextEasiness(ef, quality),
+		Interval:       interval,
+		Repetitions:    repetitions,
+		Stability:      stability,
+		Difficulty:     difficulty,
+		State:          CardStateReview,
+		NextReviewAt:   addDays(now, interval),
+	}
+}
+
+// FSRSRetrievability returns the probability of recalling a word with the
+// given stability after elapsedDays
+func FSRSRetrievability(elapsedDays, stability float64) float64 {
+	if stability <= 0 {
+		return 0
+	}
+	return math.Pow(1+fsrsFactor*elapsedDays/stability, fsrsDecay)
+}
+
+// fsrsRating maps an SM-2 quality (0-5) onto the FSRS 1-4 rating scale
+func fsrsRating(quality int) int {
+	switch {
+	case quality < 3:
+		return fsrsAgain
+	case quality == 3:
+		return fsrsHard
+	case quality == 4:
+		return fsrsGood
+	default:
+		return fsrsEasy
+	}
+}
+
+func (s *FSRSScheduler) initStability(rating int) float64 {
+	return math.Max(s.Weights[rating-1], 0.1)
+}
+
+func (s *FSRSScheduler) initDifficulty(rating int) float64 {
+	return clampDifficulty(s.Weights[4] - float64(rating-3)*s.Weights[5])
+}
+
+func (s *FSRSScheduler) nextDifficulty(difficulty float64, rating int) float64 {
+	next := difficulty - s.Weights[6]*float64(rating-3)
+	// Mean reversion towards the initial difficulty of a "good" answer
+	return clampDifficulty(s.Weights[7]*s.initDifficulty(fsrsGood) + (1-s.Weights[7])*next)
+}
+
+func (s *FSRSScheduler) recallStability(difficulty, stability, retrievability float64, rating int) float64 {
+	hardPenalty := 1.0
+	if rating == fsrsHard {
+		hardPenalty = s.Weights[15]
+	}
+	easyBonus := 1.0
+	if rating == fsrsEasy {
+		easyBonus = s.Weights[16]
+	}
+
+	return stability * (1 + math.Exp(s.Weights[8])*
+		(11-difficulty)*
+		math.Pow(stability, -s.Weights[9])*
+		(math.Exp((1-retrievability)*s.Weights[10])-1)*
+		hardPenalty*
+		easyBonus)
+}
+
+func (s *FSRSScheduler) forgetStability(difficulty, stability, retrievability float64) float64 {
+	next := s.Weights[11] *
+		math.Pow(difficulty, -s.Weights[12]) *
+		(math.Pow(stability+1, s.Weights[13]) - 1) *
+		math.Exp((1-retrievability)*s.Weights[14])
+
+	// Forgetting never makes a memory more stable than it was
+	return math.Min(next, stability)
+}
+
+// nextInterval returns the number of days until retrievability falls to the
+// requested retention
+func (s *FSRSScheduler) nextInterval(stability float64) int {
+	interval := stability / fsrsFactor * (math.Pow(s.RequestRetention, 1/fsrsDecay) - 1)
+	days := int(math.Round(interval))
+	if days < 1 {
+		days = 1
+	}
+	if days > s.MaximumInterval {
+		days = s.MaximumInterval
+	}
+	return days
+}
+
+func clampDifficulty(difficulty float64) float64 {
+	return math.Min(10, math.Max(1, difficulty))
+}
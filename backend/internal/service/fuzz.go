@@ -0,0 +1,112 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// fuzzRange is the fraction an interval may be moved by, for intervals of at least minDays
+type fuzzRange struct {
+	minDays float64
+	factor  float64
+}
+
+// intervalFuzzRanges shrink the relative fuzz as intervals grow, so long
+// intervals are not moved by weeks
+var intervalFuzzRanges = []fuzzRange{
+	{minDays: 2.5, factor: 0.15},
+	{minDays: 7, factor: 0.10},
+	{minDays: 20, factor: 0.05},
+}
+
+// FuzzBounds returns the shortest and longest interval a word scheduled for
+// interval days may be moved to. Intervals under three days are not fuzzed.
+func FuzzBounds(interval int) (int, int) {
+	if float64(interval) < intervalFuzzRanges[0].minDays {
+		return interval, interval
+	}
+
+	var delta float64
+	for _, r := range intervalFuzzRanges {
+		if float64(interval) >= r.minDays {
+			delta = float64(interval) * r.factor
+		}
+	}
+
+	spread := int(math.Max(1, math.Round(delta)))
+	lower := interval - spread
+	if lower < 2 {
+		lower = 2
+	}
+	return lower, interval + spread
+}
+
+// LoadBalancer spreads review due dates: each interval is fuzzed within
+// FuzzBounds and, when the user's upcoming due counts are known, moved to the
+// least loaded day in that range. It is safe for concurrent use.
+type LoadBalancer struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewLoadBalancer creates a load balancer drawing from src
+func NewLoadBalancer(src rand.Source) *LoadBalancer {
+	return &LoadBalancer{rng: rand.New(src)}
+}
+
+// Balance returns the interval to use instead of interval. dueCounts[d] is
+// the number of words already due d days from now; days outside it count as
+// empty. Among the least loaded days the one nearest the original interval
+// wins, with remaining ties broken at random.
+func (b *LoadBalancer) Balance(interval int, dueCounts []int) int {
+	lower, upper := FuzzBounds(interval)
+	if lower == upper {
+		return interval
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Without a distribution this is plain uniform fuzz
+	if len(dueCounts) == 0 {
+		return lower + b.rng.Intn(upper-lower+1)
+	}
+
+	var candidates []int
+	bestCount, bestDistance := math.MaxInt, math.MaxInt
+	for days := lower; days <= upper; days++ {
+		count := 0
+		if days < len(dueCounts) {
+			count = dueCounts[days]
+		}
+		distance := days - interval
+		if distance < 0 {
+			distance = -distance
+		}
+
+		switch {
+		case count < bestCount || (count == bestCount && distance < bestDistance):
+			candidates = []int{days}
+			bestCount, bestDistance = count, distance
+		case count == bestCount && distance == bestDistance:
+			candidates = append(candidates, days)
+		}
+	}
+
+	return candidates[b.rng.Intn(len(candidates))]
+}
+
+// balanceWindow returns how many days of due counts Balance needs for interval
+func balanceWindow(interval int) int {
+	_, upper := FuzzBounds(interval)
+	return upper + 1
+}
+
+// applyInterval moves a scheduled review to a new number of days after now
+func applyInterval(result ScheduleResult, interval int, now time.Time) ScheduleResult {
+	result.Interval = interval
+	result.NextReviewAt = addDays(now, interval)
+	return result
+}
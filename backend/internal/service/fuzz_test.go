@@ -0,0 +1,129 @@
+package service
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestFuzzBounds(t *testing.T) {
+	tests := []struct {
+		interval  int
+		wantLower int
+		wantUpper int
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{2, 2, 2},
+		{3, 2, 4},
+		{5, 4, 6},
+		{7, 6, 8},
+		{10, 9, 11},
+		{20, 19, 21},
+		{30, 28, 32},
+		{100, 95, 105},
+		{365, 347, 383},
+	}
+
+	for _, tt := range tests {
+		lower, upper := FuzzBounds(tt.interval)
+		if lower != tt.wantLower || upper != tt.wantUpper {
+			t.Errorf("FuzzBounds(%d) = (%d, %d), want (%d, %d)", tt.interval, lower, upper, tt.wantLower, tt.wantUpper)
+		}
+	}
+}
+
+func TestLoadBalancerBalance(t *testing.T) {
+	tests := []struct {
+		name      string
+		interval  int
+		dueCounts []int
+		want      []int // any of these
+	}{
+		{
+			name:      "short intervals are not moved",
+			interval:  2,
+			dueCounts: []int{0, 0, 9, 0},
+			want:      []int{2},
+		},
+		{
+			name:      "lightest day in range",
+			interval:  10,
+			dueCounts: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 5, 7, 2},
+			want:      []int{11},
+		},
+		{
+			name:      "lighter days out of range are ignored",
+			interval:  10,
+			dueCounts: []int{0, 0, 0, 0, 0, 0, 0, 0, 9, 6, 4, 5, 0},
+			want:      []int{10},
+		},
+		{
+			name:      "equal load keeps the original interval",
+			interval:  10,
+			dueCounts: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 3, 3},
+			want:      []int{10},
+		},
+		{
+			name:      "equal load and distance picks either day",
+			interval:  10,
+			dueCounts: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 5, 1},
+			want:      []int{9, 11},
+		},
+		{
+			name:      "days past the distribution count as empty",
+			interval:  30,
+			dueCounts: ones(31),
+			want:      []int{31},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				got := NewLoadBalancer(rand.NewSource(seed)).Balance(tt.interval, tt.dueCounts)
+				if !containsInt(tt.want, got) {
+					t.Fatalf("seed %d: Balance(%d) = %d, want one of %v", seed, tt.interval, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadBalancerUniformFuzz(t *testing.T) {
+	for _, interval := range []int{3, 10, 30, 365} {
+		lower, upper := FuzzBounds(interval)
+		a := NewLoadBalancer(rand.NewSource(42))
+		b := NewLoadBalancer(rand.NewSource(42))
+		seen := make(map[int]bool)
+		for i := 0; i < 200; i++ {
+			got := a.Balance(interval, nil)
+			if got < lower || got > upper {
+				t.Fatalf("Balance(%d) = %d, outside [%d, %d]", interval, got, lower, upper)
+			}
+			if other := b.Balance(interval, nil); other != got {
+				t.Fatalf("Balance(%d) with the same seed = %d and %d", interval, got, other)
+			}
+			seen[got] = true
+		}
+		if len(seen) != upper-lower+1 {
+			t.Errorf("Balance(%d) drew %d of the %d days in range", interval, len(seen), upper-lower+1)
+		}
+	}
+}
+
+func ones(n int) []int {
+	counts := make([]int, n)
+	for i := range counts {
+		counts[i] = 1
+	}
+	return counts
+}
+
+func containsInt(values []int, v int) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Typed answer errors
+var (
+	ErrNotTypedCard = errors.New("card is not answered by typing")
+	ErrCardNotDue   = errors.New("card is not due for review")
+	// ErrTypedCard is returned when a quality is submitted for a card the
+	// server grades from the typed answer
+	ErrTypedCard = errors.New("card is answered by typing: submit the answer to POST /api/v1/review/grade")
+)
+
+// MaxTypedAnswerLength bounds typed answers, in characters
+const MaxTypedAnswerLength = 200
+
+// Response times of a correct typed answer, on top of typingTimePerChar per
+// character of the word: up to fastAnswerTime is an easy recall, beyond
+// slowAnswerTime a hard one
+const (
+	fastAnswerTime    = 3 * time.Second
+	slowAnswerTime    = 10 * time.Second
+	typingTimePerChar = 300 * time.Millisecond
+)
+
+// Diff operations between a typed answer and the expected word
+const (
+	DiffMatch   = "match"   // typed as expected
+	DiffWrong   = "wrong"   // typed a different character
+	DiffMissing = "missing" // expected character not typed
+	DiffExtra   = "extra"   // typed character not expected
+)
+
+// DiffSegment is a run of characters sharing one diff operation
+type DiffSegment struct {
+	Op       string `json:"op"`
+	Typed    string `json:"typed,omitempty"`
+	Expected string `json:"expected,omitempty"`
+}
+
+// AnswerGrade is the server's grading of a typed answer
+type AnswerGrade struct {
+	Answer   string `json:"answer"`
+	Expected string `json:"expected"`
+	// Exact is set when the answer matches ignoring case and accents
+	Exact bool `json:"exact"`
+	// Correct is set when the answer counts as recalled, including near misses
+	Correct  bool          `json:"correct"`
+	Distance int           `json:"distance"`
+	Quality  int           `json:"quality"`
+	Diff     []DiffSegment `json:"diff"`
+}
+
+// TypedAnswerRequest represents a typed answer to a recall, spelling or cloze card
+type TypedAnswerRequest struct {
+	CardID uuid.UUID `json:"card_id"`
+	// UserWordID identifies the word's spelling card when CardID is not set
+	UserWordID     uuid.UUID `json:"user_word_id"`
+	Answer         string    `json:"answer"`
+	ResponseTimeMs *int      `json:"response_time_ms"`
+	ClientType     string    `json:"client_type"`
+	// SessionID answers the current card of a session instead
+	SessionID *uuid.UUID `json:"session_id"`
+}
+
+// Validate checks a typed answer
+func (req *TypedAnswerRequest) Validate() error {
+	if req.CardID == uuid.Nil && req.UserWordID == uuid.Nil && req.SessionID == nil {
+		return fmt.Errorf("card_id, user_word_id or session_id is required")
+	}
+	if len([]rune(req.Answer)) > MaxTypedAnswerLength {
+		return fmt.Errorf("answer must be at most %d characters", MaxTypedAnswerLength)
+	}
+
+	responseTimeMs, err := validateAnswerContext(req.ResponseTimeMs, req.ClientType)
+	if err != nil {
+		return err
+	}
+	req.ResponseTimeMs = responseTimeMs
+
+	return nil
+}
+
+// TypedAnswerResult is the grade of a typed answer and the review it recorded
+type TypedAnswerResult struct {
+	Grade  AnswerGrade    `json:"grade"`
+	Review *SessionAnswer `json:"review"`
+}
+
+// SubmitTypedAnswer grades a typed answer against the card's word and records
+// the review with the graded quality, so that clients never send a quality
+// for typed cards
+func (s *ReviewService) SubmitTypedAnswer(ctx context.Context, userID string, req TypedAnswerRequest) (*TypedAnswerResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if req.SessionID != nil {
+		return s.submitTypedSessionAnswer(ctx, userID, *req.SessionID, req)
+	}
+
+	var (
+		card *repository.DueWord
+		err  error
+	)
+	if req.CardID != uuid.Nil {
+		card, err = s.reviewRepo.GetReviewCard(ctx, userID, req.CardID)
+	} else {
+		card, err = s.reviewRepo.GetWordCard(ctx, userID, req.UserWordID, CardTypeSpelling)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card: %w", err)
+	}
+	if card == nil {
+		return nil, ErrReviewWordMissing
+	}
+	if !isTypedCard(card.CardType) {
+		return nil, ErrNotTypedCard
+	}
+
+	now := s.clock()
+	if !isDue(*card, now) {
+		return nil, ErrCardNotDue
+	}
+
+	grade := GradeTypedAnswer(card.Word, req.Answer, req.ResponseTimeMs, card.CardType)
+	review, err := s.recordAnswer(ctx, userID, *card, SubmitReviewRequest{
+		CardID:         card.CardID,
+		Quality:        grade.Quality,
+		ResponseTimeMs: req.ResponseTimeMs,
+		CardMode:       card.CardType,
+		ClientType:     req.ClientType,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedAnswerResult{Grade: grade, Review: review}, nil
+}
+
+// submitTypedSessionAnswer grades a typed answer to a session's current card
+// and answers it
+func (s *ReviewService) submitTypedSessionAnswer(ctx context.Context, userID string, sessionID uuid.UUID, req TypedAnswerRequest) (*TypedAnswerResult, error) {
+	next, err := s.NextCard(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		return nil, ErrSessionCompleted
+	}
+
+	card := next.Card
+	if req.CardID != uuid.Nil && req.CardID != card.CardID {
+		return nil, ErrNotCurrentCard
+	}
+	if !isTypedCard(card.CardType) {
+		return nil, ErrNotTypedCard
+	}
+
+	grade := GradeTypedAnswer(card.Word, req.Answer, req.ResponseTimeMs, card.CardType)
+	review, err := s.submitSessionAnswer(ctx, userID, sessionID, SubmitReviewRequest{
+		CardID:         card.CardID,
+		Quality:        grade.Quality,
+		ResponseTimeMs: req.ResponseTimeMs,
+		CardMode:       card.CardType,
+		ClientType:     req.ClientType,
+	}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedAnswerResult{Grade: grade, Review: review}, nil
+}
+
+// isTypedCard reports whether cards of a type can be answered by typing the word
+func isTypedCard(cardType string) bool {
+	return cardType == CardTypeRecall || cardType == CardTypeSpelling || cardType == CardTypeCloze
+}
+
+// GradeTypedAnswer compares a typed answer with the expected word, ignoring
+// case, accents and surrounding whitespace, and maps the result and response
+// time to an SM-2 quality:
+//
+//	5, 4, 3  exact answer, by how quickly it was typed
+//	3        near miss on a recall or cloze card: the word was recalled but misspelt
+//	2        near miss on a spelling card
+//	1        wrong, but at least half of the word was right
+//	0        wrong or blank
+func GradeTypedAnswer(expected, answer string, responseTimeMs *int, cardType string) AnswerGrade {
+	expected = strings.TrimSpace(expected)
+	answer = strings.TrimSpace(answer)
+
+	expectedRunes := []rune(expected)
+	answerRunes := []rune(answer)
+	distance, diff := diffAnswer(answerRunes, expectedRunes)
+
+	grade := AnswerGrade{
+		Answer:   answer,
+		Expected: expected,
+		Exact:    distance == 0 && len(answerRunes) > 0,
+		Distance: distance,
+		Diff:     diff,
+	}
+
+	switch {
+	case len(answerRunes) == 0:
+		grade.Quality = 0
+	case grade.Exact:
+		grade.Correct = true
+		grade.Quality = exactAnswerQuality(len(expectedRunes), responseTimeMs)
+	case distance <= nearMissDistance(len(expectedRunes)):
+		if cardType == CardTypeSpelling {
+			grade.Quality = 2
+		} else {
+			grade.Correct = true
+			grade.Quality = 3
+		}
+	case distance*2 <= len(expectedRunes):
+		grade.Quality = 1
+	default:
+		grade.Quality = 0
+	}
+
+	return grade
+}
+
+// exactAnswerQuality grades a correct answer by its response time, allowing
+// time to type each character. Without a response time it counts as a good,
+// unhurried recall.
+func exactAnswerQuality(length int, responseTimeMs *int) int {
+	if responseTimeMs == nil {
+		return 4
+	}
+
+	elapsed := time.Duration(*responseTimeMs) * time.Millisecond
+	typing := time.Duration(length) * typingTimePerChar
+	switch {
+	case elapsed <= fastAnswerTime+typing:
+		return 5
+	case elapsed <= slowAnswerTime+typing:
+		return 4
+	default:
+		return 3
+	}
+}
+
+// nearMissDistance is the number of typos still counted as a near miss. Short
+// words get none, as one letter there usually makes a different word.
+func nearMissDistance(length int) int {
+	switch {
+	case length <= 3:
+		return 0
+	case length <= 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// diffAnswer computes the Levenshtein distance between the typed and expected
+// characters, compared with foldRune, and the character-level diff that
+// turns one into the other
+func diffAnswer(typed, expected []rune) (int, []DiffSegment) {
+	rows, cols := len(typed)+1, len(expected)+1
+	dist := make([][]int, rows)
+	for i := range dist {
+		dist[i] = make([]int, cols)
+		dist[i][0] = i
+	}
+	for j := 0; j < cols; j++ {
+		dist[0][j] = j
+	}
+
+	for i := 1; i < rows; i++ {
+		for j := 1; j < cols; j++ {
+			cost := 1
+			if foldRune(typed[i-1]) == foldRune(expected[j-1]) {
+				cost = 0
+			}
+			dist[i][j] = minInt(dist[i-1][j-1]+cost, minInt(dist[i-1][j]+1, dist[i][j-1]+1))
+		}
+	}
+
+	// Walk back from the end, preferring matches and substitutions so that
+	// the diff lines up characters where it can
+	var ops []DiffSegment
+	i, j := len(typed), len(expected)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && foldRune(typed[i-1]) == foldRune(expected[j-1]) && dist[i][j] == dist[i-1][j-1]:
+			ops = append(ops, DiffSegment{Op: DiffMatch, Typed: string(typed[i-1]), Expected: string(expected[j-1])})
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && dist[i][j] == dist[i-1][j-1]+1:
+			ops = append(ops, DiffSegment{Op: DiffWrong, Typed: string(typed[i-1]), Expected: string(expected[j-1])})
+			i, j = i-1, j-1
+		case j > 0 && dist[i][j] == dist[i][j-1]+1:
+			ops = append(ops, DiffSegment{Op: DiffMissing, Expected: string(expected[j-1])})
+			j--
+		default:
+			ops = append(ops, DiffSegment{Op: DiffExtra, Typed: string(typed[i-1])})
+			i--
+		}
+	}
+
+	// Reverse into reading order, merging runs of the same operation
+	var diff []DiffSegment
+	for k := len(ops) - 1; k >= 0; k-- {
+		op := ops[k]
+		if last := len(diff) - 1; last >= 0 && diff[last].Op == op.Op {
+			diff[last].Typed += op.Typed
+			diff[last].Expected += op.Expected
+			continue
+		}
+		diff = append(diff, op)
+	}
+
+	return dist[len(typed)][len(expected)], diff
+}
+
+// accentFolds maps accented Latin letters to their base letter
+var accentFolds = buildAccentFolds(map[rune]string{
+	'a':  "àáâãäåāăą",
+	'c':  "çćĉċč",
+	'd':  "ďđ",
+	'e':  "èéêëēĕėęě",
+	'g':  "ĝğġģ",
+	'h':  "ĥħ",
+	'i':  "ìíîïĩīĭįı",
+	'j':  "ĵ",
+	'k':  "ķ",
+	'l':  "ĺļľŀł",
+	'n':  "ñńņňŉ",
+	'o':  "òóôõöøōŏő",
+	'r':  "ŕŗř",
+	's':  "śŝşšș",
+	't':  "ţťŧț",
+	'u':  "ùúûüũūŭůűų",
+	'w':  "ŵ",
+	'y':  "ýÿŷ",
+	'z':  "źżž",
+	'\'': "‘’`´",
+	'-':  "‐‑–—",
+})
+
+func buildAccentFolds(groups map[rune]string) map[rune]rune {
+	folds := make(map[rune]rune)
+	for base, variants := range groups {
+		for _, variant := range variants {
+			folds[variant] = base
+		}
+	}
+	return folds
+}
+
+// foldRune lower-cases r and strips its accent, so that "É" and "e" compare equal
+func foldRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if base, ok := accentFolds[r]; ok {
+		return base
+	}
+	return r
+}
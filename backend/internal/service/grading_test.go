@@ -0,0 +1,143 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestGradeTypedAnswer(t *testing.T) {
+	tests := []struct {
+		name           string
+		expected       string
+		answer         string
+		responseTimeMs *int
+		cardType       string
+		wantExact      bool
+		wantCorrect    bool
+		wantDistance   int
+		wantQuality    int
+	}{
+		{"exact without response time", "apple", "apple", nil, CardTypeRecall, true, true, 0, 4},
+		{"exact and fast", "apple", "apple", intPtr(2000), CardTypeRecall, true, true, 0, 5},
+		{"exact and slow", "apple", "apple", intPtr(60000), CardTypeRecall, true, true, 0, 3},
+		{"surrounding whitespace", "apple", "  apple\n", nil, CardTypeRecall, true, true, 0, 4},
+		{"case difference", "Apple", "APPLE", nil, CardTypeSpelling, true, true, 0, 4},
+		{"accent difference", "café", "cafe", nil, CardTypeSpelling, true, true, 0, 4},
+		{"accented answer", "naive", "naïve", nil, CardTypeCloze, true, true, 0, 4},
+		{"one typo on a recall card", "apple", "aple", nil, CardTypeRecall, false, true, 1, 3},
+		{"one typo on a spelling card", "apple", "aple", nil, CardTypeSpelling, false, false, 1, 2},
+		{"one typo on a short word", "cat", "cot", nil, CardTypeRecall, false, false, 1, 1},
+		{"two typos on a long word", "necessary", "neccesary", nil, CardTypeRecall, false, true, 2, 3},
+		{"two typos on a medium word", "apple", "appel", nil, CardTypeRecall, false, false, 2, 1},
+		{"wrong word", "apple", "orange", nil, CardTypeRecall, false, false, 5, 0},
+		{"empty answer", "apple", "", nil, CardTypeRecall, false, false, 5, 0},
+		{"blank answer", "apple", "   ", intPtr(1000), CardTypeRecall, false, false, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grade := GradeTypedAnswer(tt.expected, tt.answer, tt.responseTimeMs, tt.cardType)
+			if grade.Exact != tt.wantExact {
+				t.Errorf("Exact = %v, want %v", grade.Exact, tt.wantExact)
+			}
+			if grade.Correct != tt.wantCorrect {
+				t.Errorf("Correct = %v, want %v", grade.Correct, tt.wantCorrect)
+			}
+			if grade.Distance != tt.wantDistance {
+				t.Errorf("Distance = %d, want %d", grade.Distance, tt.wantDistance)
+			}
+			if grade.Quality != tt.wantQuality {
+				t.Errorf("Quality = %d, want %d", grade.Quality, tt.wantQuality)
+			}
+		})
+	}
+}
+
+func TestDiffAnswer(t *testing.T) {
+	tests := []struct {
+		name         string
+		typed        string
+		expected     string
+		wantDistance int
+		wantDiff     []DiffSegment
+	}{
+		{
+			name:     "exact",
+			typed:    "apple",
+			expected: "apple",
+			wantDiff: []DiffSegment{{Op: DiffMatch, Typed: "apple", Expected: "apple"}},
+		},
+		{
+			name:     "case and accents match",
+			typed:    "CAFE",
+			expected: "café",
+			wantDiff: []DiffSegment{{Op: DiffMatch, Typed: "CAFE", Expected: "café"}},
+		},
+		{
+			name:         "missing character",
+			typed:        "aple",
+			expected:     "apple",
+			wantDistance: 1,
+			wantDiff: []DiffSegment{
+				{Op: DiffMatch, Typed: "a", Expected: "a"},
+				{Op: DiffMissing, Expected: "p"},
+				{Op: DiffMatch, Typed: "ple", Expected: "ple"},
+			},
+		},
+		{
+			name:         "extra character",
+			typed:        "applle",
+			expected:     "apple",
+			wantDistance: 1,
+			wantDiff: []DiffSegment{
+				{Op: DiffMatch, Typed: "app", Expected: "app"},
+				{Op: DiffExtra, Typed: "l"},
+				{Op: DiffMatch, Typed: "le", Expected: "le"},
+			},
+		},
+		{
+			name:         "empty answer",
+			typed:        "",
+			expected:     "apple",
+			wantDistance: 5,
+			wantDiff:     []DiffSegment{{Op: DiffMissing, Expected: "apple"}},
+		},
+		{
+			name:         "multi-byte expected character",
+			typed:        "Zorich",
+			expected:     "Zürich",
+			wantDistance: 1,
+			wantDiff: []DiffSegment{
+				{Op: DiffMatch, Typed: "Z", Expected: "Z"},
+				{Op: DiffWrong, Typed: "o", Expected: "ü"},
+				{Op: DiffMatch, Typed: "rich", Expected: "rich"},
+			},
+		},
+		{
+			name:         "multi-byte words",
+			typed:        "日本",
+			expected:     "日本語",
+			wantDistance: 1,
+			wantDiff: []DiffSegment{
+				{Op: DiffMatch, Typed: "日本", Expected: "日本"},
+				{Op: DiffMissing, Expected: "語"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance, diff := diffAnswer([]rune(tt.typed), []rune(tt.expected))
+			if distance != tt.wantDistance {
+				t.Errorf("distance = %d, want %d", distance, tt.wantDistance)
+			}
+			if !reflect.DeepEqual(diff, tt.wantDiff) {
+				t.Errorf("diff = %+v, want %+v", diff, tt.wantDiff)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// MaxGroupNameLength bounds group names, in characters, matching the column
+const MaxGroupNameLength = 100
+
+// DefaultGroupColor is the colour of groups created without one, matching
+// the column default
+const DefaultGroupColor = "#3B82F6"
+
+// colorPattern matches the #RRGGBB colours groups and tags are stored with
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Group errors
+var (
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrGroupNameTaken = repository.ErrGroupNameTaken
+)
+
+// GroupService manages users' word groups
+type GroupService struct {
+	groupRepo *repository.GroupRepository
+}
+
+// NewGroupService creates a new group service instance
+func NewGroupService(groupRepo *repository.GroupRepository) *GroupService {
+	return &GroupService{groupRepo: groupRepo}
+}
+
+// CreateGroupRequest represents a new group
+type CreateGroupRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// Validate checks the name and colour, trimming the name and defaulting the colour
+func (req *CreateGroupRequest) Validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateGroupName(req.Name); err != nil {
+		return err
+	}
+	if req.Color == "" {
+		req.Color = DefaultGroupColor
+	}
+	return validateColor(req.Color)
+}
+
+// UpdateGroupRequest renames or recolours a group; omitted fields are kept
+type UpdateGroupRequest repository.GroupUpdate
+
+// Validate checks that something changes and the new name and colour
+func (req *UpdateGroupRequest) Validate() error {
+	if req.Name == nil && req.Color == nil {
+		return fmt.Errorf("name or color is required")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+		if err := validateGroupName(name); err != nil {
+			return err
+		}
+	}
+	if req.Color != nil {
+		return validateColor(*req.Color)
+	}
+	return nil
+}
+
+// ReorderGroupsRequest lists the user's groups in their new order. Groups
+// left out keep their relative order after the listed ones, so a client may
+// send only the groups it moved to the top.
+type ReorderGroupsRequest struct {
+	GroupIDs []uuid.UUID `json:"group_ids"`
+}
+
+// Validate checks that the order names each group once
+func (req *ReorderGroupsRequest) Validate() error {
+	if len(req.GroupIDs) == 0 {
+		return fmt.Errorf("group_ids is required")
+	}
+	seen := make(map[uuid.UUID]bool, len(req.GroupIDs))
+	for _, id := range req.GroupIDs {
+		if seen[id] {
+			return fmt.Errorf("group %s is listed twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// MoveWordsRequest moves every word matching a filter into a group, or out
+// of its group when GroupID is nil
+type MoveWordsRequest struct {
+	GroupID *uuid.UUID            `json:"group_id"`
+	Filter  repository.WordFilter `json:"filter"`
+}
+
+// Validate checks that the filter selects some words rather than all
+func (req *MoveWordsRequest) Validate() error {
+	if req.Filter.IsEmpty() {
+		return ErrEmptyFilter
+	}
+	return req.Filter.Validate()
+}
+
+// MoveWordsResult is the number of words moved into a group, or out of
+// their groups when GroupID is nil
+type MoveWordsResult struct {
+	GroupID *uuid.UUID `json:"group_id"`
+	Words   int        `json:"words"`
+}
+
+func validateGroupName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(name) > MaxGroupNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxGroupNameLength)
+	}
+	return nil
+}
+
+func validateColor(color string) error {
+	if !colorPattern.MatchString(color) {
+		return fmt.Errorf("color must be a hex colour like #3B82F6")
+	}
+	return nil
+}
+
+// ListGroups returns the user's groups in their sort order with word and due counts
+func (s *GroupService) ListGroups(ctx context.Context, userID string) ([]repository.Group, error) {
+	return s.groupRepo.ListGroups(ctx, userID)
+}
+
+// GetGroup returns one of the user's groups with its counts
+func (s *GroupService) GetGroup(ctx context.Context, userID string, groupID uuid.UUID) (*repository.Group, error) {
+	group, err := s.groupRepo.GetGroup(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// CreateGroup adds a group after the user's existing ones
+func (s *GroupService) CreateGroup(ctx context.Context, userID string, req CreateGroupRequest) (*repository.Group, error) {
+	return s.groupRepo.CreateGroup(ctx, userID, req.Name, req.Color)
+}
+
+// UpdateGroup renames or recolours one of the user's groups
+func (s *GroupService) UpdateGroup(ctx context.Context, userID string, groupID uuid.UUID, req UpdateGroupRequest) (*repository.Group, error) {
+	group, err := s.groupRepo.UpdateGroup(ctx, userID, groupID, repository.GroupUpdate(req))
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, ErrGroupNotFound
+	}
+	return group, nil
+}
+
+// DeleteGroup removes one of the user's groups, leaving its words ungrouped
+func (s *GroupService) DeleteGroup(ctx context.Context, userID string, groupID uuid.UUID) error {
+	deleted, err := s.groupRepo.DeleteGroup(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrGroupNotFound
+	}
+	return nil
+}
+
+// ReorderGroups sorts the user's groups in the order given, renumbering the
+// ones left out after them, and returns them
+func (s *GroupService) ReorderGroups(ctx context.Context, userID string, req ReorderGroupsRequest) ([]repository.Group, error) {
+	count, err := s.groupRepo.ReorderGroups(ctx, userID, req.GroupIDs)
+	if err != nil {
+		return nil, err
+	}
+	if count < len(req.GroupIDs) {
+		return nil, ErrGroupNotFound
+	}
+	return s.groupRepo.ListGroups(ctx, userID)
+}
+
+// MoveWord moves one of the user's words into a group, or out of its group
+// when groupID is nil
+func (s *GroupService) MoveWord(ctx context.Context, userID string, userWordID uuid.UUID, groupID *uuid.UUID) (*MoveWordsResult, error) {
+	result, err := s.MoveWords(ctx, userID, MoveWordsRequest{
+		GroupID: groupID,
+		Filter:  repository.WordFilter{UserWordIDs: []uuid.UUID{userWordID}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Words == 0 {
+		return nil, ErrReviewWordMissing
+	}
+	return result, nil
+}
+
+// MoveWords moves every word matching the request's filter into its group,
+// or out of their groups when it has none
+func (s *GroupService) MoveWords(ctx context.Context, userID string, req MoveWordsRequest) (*MoveWordsResult, error) {
+	if req.GroupID != nil {
+		if _, err := s.GetGroup(ctx, userID, *req.GroupID); err != nil {
+			return nil, err
+		}
+	}
+
+	count, err := s.groupRepo.MoveWords(ctx, userID, req.GroupID, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MoveWordsResult{GroupID: req.GroupID, Words: count}, nil
+}
+
+// RemoveWord takes one of the user's words out of the group it is in
+func (s *GroupService) RemoveWord(ctx context.Context, userID string, groupID, userWordID uuid.UUID) (*MoveWordsResult, error) {
+	count, err := s.groupRepo.MoveWords(ctx, userID, nil, repository.WordFilter{
+		UserWordIDs: []uuid.UUID{userWordID},
+		GroupID:     &groupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrReviewWordMissing
+	}
+	return &MoveWordsResult{Words: count}, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Leech thresholds, in lapses of one card
+const (
+	DefaultLeechThreshold = 8
+	MinLeechThreshold     = 2
+	MaxLeechThreshold     = 50
+)
+
+// Leech actions: what happens when a word becomes a leech
+const (
+	LeechActionTag     = "tag"     // flag the word and keep reviewing it
+	LeechActionSuspend = "suspend" // flag the word and stop reviewing it
+)
+
+// MaxWordNoteLength bounds mnemonics and custom definitions, in characters
+const MaxWordNoteLength = 2000
+
+// ValidateLeechSettings checks a leech threshold and action
+func ValidateLeechSettings(threshold int, action string) error {
+	if threshold < MinLeechThreshold || threshold > MaxLeechThreshold {
+		return fmt.Errorf("leech threshold must be between %d and %d", MinLeechThreshold, MaxLeechThreshold)
+	}
+	if action != LeechActionTag && action != LeechActionSuspend {
+		return fmt.Errorf("leech action must be %q or %q", LeechActionTag, LeechActionSuspend)
+	}
+	return nil
+}
+
+// UpdateWordNotesRequest represents a rewrite of a word's definition or mnemonic
+type UpdateWordNotesRequest struct {
+	// Pointers so that an omitted field is left unchanged; "" clears it
+	Mnemonic         *string `json:"mnemonic"`
+	CustomDefinition *string `json:"custom_definition"`
+}
+
+// Validate checks the notes' lengths
+func (req *UpdateWordNotesRequest) Validate() error {
+	if req.Mnemonic == nil && req.CustomDefinition == nil {
+		return fmt.Errorf("mnemonic or custom_definition is required")
+	}
+	if req.Mnemonic != nil && len([]rune(*req.Mnemonic)) > MaxWordNoteLength {
+		return fmt.Errorf("mnemonic must be at most %d characters", MaxWordNoteLength)
+	}
+	if req.CustomDefinition != nil && len([]rune(*req.CustomDefinition)) > MaxWordNoteLength {
+		return fmt.Errorf("custom_definition must be at most %d characters", MaxWordNoteLength)
+	}
+	return nil
+}
+
+// GetLeeches returns the user's leeches, most lapses first
+func (s *ReviewService) GetLeeches(ctx context.Context, userID string) ([]repository.Leech, error) {
+	leeches, err := s.reviewRepo.GetLeeches(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leeches: %w", err)
+	}
+
+	return leeches, nil
+}
+
+// UpdateWordNotes rewrites the definition or mnemonic shown with a word's
+// cards, typically to help with a leech
+func (s *ReviewService) UpdateWordNotes(ctx context.Context, userID string, userWordID uuid.UUID, req UpdateWordNotesRequest) (*repository.Leech, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	word, err := s.reviewRepo.UpdateWordNotes(ctx, userID, userWordID, req.Mnemonic, req.CustomDefinition)
+	if err != nil {
+		return nil, err
+	}
+	if word == nil {
+		return nil, ErrReviewWordMissing
+	}
+
+	return word, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"vocabweb/internal/repository"
+)
+
+// MinFitReviews is the number of scored reviews needed before personal
+// parameters are fitted; below it the defaults are kept
+const MinFitReviews = 50
+
+// ErrNotEnoughHistory is returned when a user has too few reviews to fit parameters
+var ErrNotEnoughHistory = errors.New("not enough review history")
+
+// ParameterFit describes a user's SM-2 parameters and how well they predict
+// the user's review history. It is stored as JSON in profiles.scheduler_params.
+type ParameterFit struct {
+	Params               SM2Params  `json:"params"`
+	Fitted               bool       `json:"fitted"`
+	ReviewCount          int        `json:"review_count"`
+	LogLikelihood        float64    `json:"log_likelihood"`
+	DefaultLogLikelihood float64    `json:"default_log_likelihood"`
+	ExpectedRetention    float64    `json:"expected_retention"`
+	ActualRetention      float64    `json:"actual_retention"`
+	FittedAt             *time.Time `json:"fitted_at,omitempty"`
+}
+
+// SM2Retrievability is the recall probability assumed for an SM-2 card:
+// exponential forgetting that reaches 90% once elapsedDays equals the
+// card's stability (its unscaled SM-2 interval)
+func SM2Retrievability(elapsedDays, stability float64) float64 {
+	if stability <= 0 {
+		return 0
+	}
+	return math.Pow(0.9, elapsedDays/stability)
+}
+
+// paramEvaluation is the fit of one parameter set to a review history
+type paramEvaluation struct {
+	logLikelihood float64
+	predicted     float64
+	recalled      int
+	count         int
+}
+
+// evaluateSM2Params replays history with params and scores every review made
+// after a card graduated: the log-likelihood of its outcome (recalled when
+// quality >= 3) under SM2Retrievability at the elapsed time
+func evaluateSM2Params(params SM2Params, settings repository.SchedulerSettings, history []repository.CardHistory) paramEvaluation {
+	retention := settings.DesiredRetention
+	if retention <= 0 {
+		retention = DefaultDesiredRetention
+	}
+	scheduler := NewStepScheduler(&SM2Scheduler{Params: params, DesiredRetention: retention}, settings.LearningSteps, settings.RelearningSteps)
+
+	var eval paramEvaluation
+	for _, card := range history {
+		replayReviews(scheduler, card.Reviews, func(before CardState, review repository.ReviewEvent, _ ScheduleResult) {
+			if before.State != CardStateReview || before.Stability <= 0 || before.LastReviewedAt == nil {
+				return
+			}
+
+			elapsed := review.ReviewedAt.Sub(*before.LastReviewedAt).Hours() / 24
+			p := math.Min(math.Max(SM2Retrievability(elapsed, before.Stability), 1e-4), 1-1e-4)
+
+			if review.Quality >= 3 {
+				eval.logLikelihood += math.Log(p)
+				eval.recalled++
+			} else {
+				eval.logLikelihood += math.Log(1 - p)
+			}
+			eval.predicted += p
+			eval.count++
+		})
+	}
+
+	return eval
+}
+
+// sm2ParamDimension is one coordinate of the parameter search grid
+type sm2ParamDimension struct {
+	values []float64
+	set    func(p *SM2Params, v float64)
+}
+
+var sm2ParamGrid = []sm2ParamDimension{
+	{values: floatRange(1.3, 3.5, 0.1), set: func(p *SM2Params, v float64) { p.InitialEasiness = v }},
+	// The floor cannot go below 1.3, the minimum allowed by the database
+	{values: floatRange(1.3, 2.0, 0.1), set: func(p *SM2Params, v float64) { p.MinEasiness = v }},
+	{values: floatRange(1, 5, 1), set: func(p *SM2Params, v float64) { p.FirstInterval = int(v) }},
+	{values: floatRange(2, 15, 1), set: func(p *SM2Params, v float64) { p.SecondInterval = int(v) }},
+}
+
+// maxFitPasses bounds the coordinate search
+const maxFitPasses = 5
+
+// FitSM2Params fits SM-2 parameters to a user's review history by maximising
+// the log-likelihood of recall, using a coordinate search over a bounded grid.
+// Histories with fewer than MinFitReviews scored reviews keep the defaults.
+func FitSM2Params(settings repository.SchedulerSettings, history []repository.CardHistory) ParameterFit {
+	best := DefaultSM2Params
+	bestEval := evaluateSM2Params(best, settings, history)
+	defaultLL := bestEval.logLikelihood
+
+	if bestEval.count >= MinFitReviews {
+		for pass := 0; pass < maxFitPasses; pass++ {
+			improved := false
+			for _, dim := range sm2ParamGrid {
+				for _, v := range dim.values {
+					candidate := best
+					dim.set(&candidate, v)
+					if candidate.MinEasiness > candidate.InitialEasiness || candidate.FirstInterval >= candidate.SecondInterval {
+						continue
+					}
+
+					eval := evaluateSM2Params(candidate, settings, history)
+					if eval.logLikelihood > bestEval.logLikelihood+1e-9 {
+						best, bestEval, improved = candidate, eval, true
+					}
+				}
+			}
+			if !improved {
+				break
+			}
+		}
+	}
+
+	fit := ParameterFit{
+		Params:               best,
+		Fitted:               bestEval.count >= MinFitReviews,
+		ReviewCount:          bestEval.count,
+		LogLikelihood:        bestEval.logLikelihood,
+		DefaultLogLikelihood: defaultLL,
+	}
+	if bestEval.count > 0 {
+		fit.ExpectedRetention = bestEval.predicted / float64(bestEval.count)
+		fit.ActualRetention = float64(bestEval.recalled) / float64(bestEval.count)
+	}
+
+	return fit
+}
+
+// floatRange returns from, from+step, ... up to and including to
+func floatRange(from, to, step float64) []float64 {
+	var values []float64
+	for i := 0; ; i++ {
+		v := math.Round((from+float64(i)*step)*100) / 100
+		if v > to+1e-9 {
+			break
+		}
+		values = append(values, v)
+	}
+	return values
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"vocabweb/internal/repository"
+)
+
+// ProjectionRetentions are the desired retention values compared by ProjectWorkload
+var ProjectionRetentions = []float64{0.70, 0.75, 0.80, 0.85, 0.90, 0.95, 0.97}
+
+// Projection horizon limits in days
+const (
+	DefaultProjectionDays = 30
+	MaxProjectionDays     = 365
+)
+
+// projectionRuns is the number of simulations averaged per retention value
+const projectionRuns = 8
+
+// maxSimulatedReviewsPerCard stops a card from looping forever in learning steps
+const maxSimulatedReviewsPerCard = 200
+
+// RetentionProjection is the simulated workload at one desired retention
+type RetentionProjection struct {
+	DesiredRetention    float64   `json:"desired_retention"`
+	Current             bool      `json:"current"`
+	AverageDailyReviews float64   `json:"average_daily_reviews"`
+	TotalReviews        float64   `json:"total_reviews"`
+	DailyReviews        []float64 `json:"daily_reviews"`
+	// ExpectedRecall is the predicted share of these reviews answered correctly
+	ExpectedRecall float64 `json:"expected_recall"`
+	// ExpectedKnown is the predicted number of cards still remembered at the end of the horizon
+	ExpectedKnown float64 `json:"expected_known"`
+}
+
+// WorkloadProjection compares the review workload of a user's existing cards
+// across desired retention values
+type WorkloadProjection struct {
+	Days             int                   `json:"days"`
+	Cards            int                   `json:"cards"`
+	Scheduler        string                `json:"scheduler"`
+	DesiredRetention float64               `json:"desired_retention"`
+	Projections      []RetentionProjection `json:"projections"`
+}
+
+// ProjectWorkload simulates the next days of reviews of every card the user
+// has already studied, once per desired retention. Answers are drawn from the
+// scheduler's forgetting curve, so lower retention means fewer but less
+// successful reviews. New cards are not included.
+func (s *ReviewService) ProjectWorkload(ctx context.Context, userID string, days int) (*WorkloadProjection, error) {
+	if days <= 0 {
+		days = DefaultProjectionDays
+	}
+	if days > MaxProjectionDays {
+		days = MaxProjectionDays
+	}
+
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	words, err := s.reviewRepo.GetScheduledWords(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled words: %w", err)
+	}
+
+	current := settings.DesiredRetention
+	if current <= 0 {
+		current = DefaultDesiredRetention
+	}
+
+	retentions := append([]float64{}, ProjectionRetentions...)
+	if !containsRetention(retentions, current) {
+		retentions = append(retentions, current)
+		sort.Float64s(retentions)
+	}
+
+	projection := &WorkloadProjection{
+		Days:             days,
+		Cards:            len(words),
+		Scheduler:        settings.Scheduler,
+		DesiredRetention: current,
+		Projections:      make([]RetentionProjection, 0, len(retentions)),
+	}
+
+	now := s.clock()
+	for _, retention := range retentions {
+		variant := *settings
+		variant.DesiredRetention = retention
+
+		scheduler, err := NewUserScheduler(variant)
+		if err != nil {
+			return nil, err
+		}
+
+		result := simulateWorkload(scheduler, words, retention, now, days)
+		result.Current = math.Abs(retention-current) < 1e-9
+		projection.Projections = append(projection.Projections, result)
+	}
+
+	return projection, nil
+}
+
+// simulateWorkload runs projectionRuns simulations of words at one retention
+// and averages them. The random source is seeded so that every retention
+// value sees the same sequence of draws and results are repeatable.
+func simulateWorkload(scheduler Scheduler, words []repository.DueWord, retention float64, now time.Time, days int) RetentionProjection {
+	horizon := addDays(now, days)
+	daily := make([]float64, days)
+	var total, recalled, known float64
+
+	for run := 0; run < projectionRuns; run++ {
+		rng := rand.New(rand.NewSource(int64(run + 1)))
+
+		for _, word := range words {
+			due := projectedDueDate(scheduler.Name(), word, retention, now)
+			state := simulateCard(scheduler, cardStateFromDueWord(word), due, horizon, retention, rng, func(at time.Time, wasRecalled bool) {
+				daily[int(at.Sub(now).Hours()/24)]++
+				total++
+				if wasRecalled {
+					recalled++
+				}
+			})
+
+			known += recallProbability(scheduler.Name(), state, horizon, retention)
+		}
+	}
+
+	runs := float64(projectionRuns)
+	for i := range daily {
+		daily[i] /= runs
+	}
+
+	result := RetentionProjection{
+		DesiredRetention:    retention,
+		AverageDailyReviews: total / runs / float64(days),
+		TotalReviews:        total / runs,
+		DailyReviews:        daily,
+		ExpectedKnown:       known / runs,
+	}
+	if total > 0 {
+		result.ExpectedRecall = recalled / total
+	}
+
+	return result
+}
+
+// simulateCard simulates the reviews of one card from due until horizon,
+// drawing each answer from the forgetting curve. review is called for every
+// simulated review, and the card's final state is returned.
+func simulateCard(scheduler Scheduler, state CardState, due, horizon time.Time, retention float64, rng *rand.Rand, review func(at time.Time, recalled bool)) CardState {
+	for i := 0; i < maxSimulatedReviewsPerCard && due.Before(horizon); i++ {
+		p := recallProbability(scheduler.Name(), state, due, retention)
+		quality := 1
+		recalled := rng.Float64() < p
+		if recalled {
+			quality = 4
+		}
+		review(due, recalled)
+
+		result := scheduler.Schedule(state, quality, due)
+		reviewedAt := due
+		state = CardState{
+			EasinessFactor: result.EasinessFactor,
+			Interval:       result.Interval,
+			Repetitions:    result.Repetitions,
+			Stability:      result.Stability,
+			Difficulty:     result.Difficulty,
+			State:          result.State,
+			Step:           result.Step,
+			LastReviewedAt: &reviewedAt,
+		}
+		due = result.NextReviewAt
+	}
+
+	return state
+}
+
+// projectedDueDate is when a word would next be due at the given retention.
+// Graduated words are re-timed from their last review using their stability,
+// learning words keep their scheduled step, and overdue words are due now.
+func projectedDueDate(schedulerName string, word repository.DueWord, retention float64, now time.Time) time.Time {
+	due := now
+	if word.NextReviewAt != nil {
+		due = *word.NextReviewAt
+	}
+
+	state := normalizeCardState(cardStateFromDueWord(word))
+	if state == CardStateReview && word.Stability > 0 && word.LastReviewedAt != nil {
+		days := retentionIntervalFor(schedulerName, word.Stability, retention)
+		due = addDays(*word.LastReviewedAt, days)
+	}
+
+	if due.Before(now) {
+		return now
+	}
+	return due
+}
+
+// recallProbability is the chance of recalling a card at time at, under the
+// forgetting curve of the scheduler. Cards without a memory state yet
+// (still in their first learning steps) are recalled at the target retention.
+func recallProbability(schedulerName string, state CardState, at time.Time, retention float64) float64 {
+	if state.Stability <= 0 || state.LastReviewedAt == nil {
+		return retention
+	}
+
+	elapsed := math.Max(0, at.Sub(*state.LastReviewedAt).Hours()/24)
+	if schedulerName == SchedulerFSRS {
+		return FSRSRetrievability(elapsed, state.Stability)
+	}
+	return SM2Retrievability(elapsed, state.Stability)
+}
+
+// retentionIntervalFor returns the days until a card of the given stability
+// falls to retention under the scheduler's forgetting curve
+func retentionIntervalFor(schedulerName string, stability, retention float64) int {
+	if schedulerName == SchedulerFSRS {
+		fsrs := NewFSRSScheduler()
+		fsrs.RequestRetention = retention
+		return fsrs.nextInterval(stability)
+	}
+	return retentionInterval(stability, retention)
+}
+
+// cardStateFromDueWord returns the scheduler input for a word's stored state
+func cardStateFromDueWord(word repository.DueWord) CardState {
+	return CardState{
+		EasinessFactor: word.EasinessFactor,
+		Interval:       word.Interval,
+		Repetitions:    word.Repetitions,
+		Stability:      word.Stability,
+		Difficulty:     word.Difficulty,
+		State:          word.CardState,
+		Step:           word.LearningStep,
+		LastReviewedAt: word.LastReviewedAt,
+	}
+}
+
+func containsRetention(retentions []float64, retention float64) bool {
+	for _, r := range retentions {
+		if math.Abs(r-retention) < 1e-9 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// RescheduleService rebuilds the scheduling state of cards by replaying
+// their review_logs through the user's current scheduler, so that changes
+// to scheduler parameters apply to cards that were scheduled before them
+type RescheduleService struct {
+	reviewRepo *repository.ReviewRepository
+}
+
+// NewRescheduleService creates a new reschedule service instance
+func NewRescheduleService(reviewRepo *repository.ReviewRepository) *RescheduleService {
+	return &RescheduleService{
+		reviewRepo: reviewRepo,
+	}
+}
+
+// RescheduleReport summarises how replaying a user's history moves their
+// due dates. Moved cards are the ones due Earlier or Later; cards without a
+// due date that replaying gives one are counted as Scheduled instead.
+type RescheduleReport struct {
+	UserID           string  `json:"user_id"`
+	Cards            int     `json:"cards"`
+	Moved            int     `json:"moved"`
+	Earlier          int     `json:"earlier"`
+	Later            int     `json:"later"`
+	Scheduled        int     `json:"scheduled"`
+	AverageShiftDays float64 `json:"average_shift_days"`
+	MaxShiftDays     float64 `json:"max_shift_days"`
+}
+
+// movedThreshold ignores due date differences caused only by timestamp rounding
+const movedThreshold = time.Minute
+
+// RescheduleUser replays one user's review history. With dryRun set nothing
+// is written and the report only describes what would change.
+func (s *RescheduleService) RescheduleUser(ctx context.Context, userID string, dryRun bool) (*RescheduleReport, error) {
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	scheduler, err := NewUserScheduler(*settings)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.reviewRepo.GetReviewHistory(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review history: %w", err)
+	}
+
+	report := &RescheduleReport{UserID: userID, Cards: len(history)}
+	schedules := make(map[uuid.UUID]repository.CardSchedule, len(history))
+	var totalShift float64
+
+	for _, card := range history {
+		schedule := ReplayHistory(scheduler, card.Reviews)
+		schedules[card.CardID] = schedule
+
+		if card.NextReviewAt == nil {
+			report.Scheduled++
+			continue
+		}
+
+		shift := schedule.NextReviewAt.Sub(*card.NextReviewAt)
+		if math.Abs(float64(shift)) < float64(movedThreshold) {
+			continue
+		}
+
+		report.Moved++
+		if shift < 0 {
+			report.Earlier++
+		} else {
+			report.Later++
+		}
+
+		shiftDays := math.Abs(shift.Hours() / 24)
+		totalShift += shiftDays
+		if shiftDays > report.MaxShiftDays {
+			report.MaxShiftDays = shiftDays
+		}
+	}
+
+	if report.Moved > 0 {
+		report.AverageShiftDays = totalShift / float64(report.Moved)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if err := s.reviewRepo.UpdateCardSchedules(ctx, schedules); err != nil {
+		return nil, fmt.Errorf("failed to rewrite schedules: %w", err)
+	}
+
+	return report, nil
+}
+
+// RescheduleAll replays the review history of every user with review logs
+func (s *RescheduleService) RescheduleAll(ctx context.Context, dryRun bool) ([]*RescheduleReport, error) {
+	userIDs, err := s.reviewRepo.ListReviewedUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	reports := make([]*RescheduleReport, 0, len(userIDs))
+	for _, userID := range userIDs {
+		report, err := s.RescheduleUser(ctx, userID, dryRun)
+		if err != nil {
+			return reports, fmt.Errorf("failed to reschedule user %s: %w", userID, err)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// ReplayHistory runs a card's reviews, oldest first, through scheduler
+// starting from a new card, and returns the resulting scheduling state
+func ReplayHistory(scheduler Scheduler, reviews []repository.ReviewEvent) repository.CardSchedule {
+	var schedule repository.CardSchedule
+	var lapses int
+
+	replayReviews(scheduler, reviews, func(before CardState, review repository.ReviewEvent, result ScheduleResult) {
+		if review.Quality < 3 && normalizeCardState(before) == CardStateReview {
+			lapses++
+		}
+		schedule = repository.CardSchedule{
+			EasinessFactor: result.EasinessFactor,
+			Interval:       result.Interval,
+			Repetitions:    result.Repetitions,
+			Stability:      result.Stability,
+			Difficulty:     result.Difficulty,
+			CardState:      result.State,
+			LearningStep:   result.Step,
+			LastReviewedAt: review.ReviewedAt,
+			NextReviewAt:   result.NextReviewAt,
+			Lapses:         lapses,
+		}
+	})
+
+	return schedule
+}
+
+// replayReviews schedules each review in turn starting from a new card,
+// calling visit with the state before the review and the scheduler's result
+func replayReviews(scheduler Scheduler, reviews []repository.ReviewEvent, visit func(before CardState, review repository.ReviewEvent, result ScheduleResult)) {
+	state := CardState{EasinessFactor: 2.5, State: CardStateNew}
+
+	for _, review := range reviews {
+		result := scheduler.Schedule(state, review.Quality, review.ReviewedAt)
+		visit(state, review, result)
+
+		reviewedAt := review.ReviewedAt
+		state = CardState{
+			EasinessFactor: result.EasinessFactor,
+			Interval:       result.Interval,
+			Repetitions:    result.Repetitions,
+			Stability:      result.Stability,
+			Difficulty:     result.Difficulty,
+			State:          result.State,
+			Step:           result.Step,
+			LastReviewedAt: &reviewedAt,
+		}
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/rand"
 	"time"
 
 	"vocabweb/internal/repository"
@@ -13,17 +15,40 @@ import (
 // ReviewService handles review business logic
 type ReviewService struct {
 	reviewRepo *repository.ReviewRepository
+	balancer   *LoadBalancer
+	clock      func() time.Time
+	dashboard  *DashboardService
 }
 
 // NewReviewService creates a new review service instance
 func NewReviewService(reviewRepo *repository.ReviewRepository) *ReviewService {
 	return &ReviewService{
 		reviewRepo: reviewRepo,
+		balancer:   NewLoadBalancer(rand.NewSource(time.Now().UnixNano())),
+		clock:      time.Now,
 	}
 }
 
-// GetDueReviews retrieves words that need review, sorted by forgetting probability
-func (s *ReviewService) GetDueReviews(ctx context.Context, userID string, limit int) ([]repository.DueWord, error) {
+// SetClock replaces the time source used to timestamp and schedule reviews
+func (s *ReviewService) SetClock(clock func() time.Time) {
+	s.clock = clock
+}
+
+// SetRandSource replaces the random source used to fuzz review intervals
+func (s *ReviewService) SetRandSource(src rand.Source) {
+	s.balancer = NewLoadBalancer(src)
+}
+
+// SetDashboardService sets the dashboard cache to invalidate when a user's
+// reviews or words change
+func (s *ReviewService) SetDashboardService(dashboard *DashboardService) {
+	s.dashboard = dashboard
+}
+
+// GetDueReviews retrieves cards to study now: due reviews sorted by forgetting
+// probability, with new cards mixed in up to the user's daily limits and in
+// their chosen order. At most one card per word is returned.
+func (s *ReviewService) GetDueReviews(ctx context.Context, userID string, limit int) ([]ReviewCard, error) {
 	if limit <= 0 {
 		limit = 20 // Default limit
 	}
@@ -31,82 +56,327 @@ func (s *ReviewService) GetDueReviews(ctx context.Context, userID string, limit
 		limit = 100 // Max limit to prevent overload
 	}
 
-	dueWords, err := s.reviewRepo.GetDueWords(ctx, userID, limit)
+	cards, _, err := s.studyCards(ctx, userID, limit, repository.WordFilter{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get due reviews: %w", err)
 	}
 
-	return dueWords, nil
+	return newReviewCards(cards), nil
 }
 
+// Card modes: which side of the word was shown and how it was answered
+const (
+	CardModeRecognition = "recognition" // word shown, meaning recalled
+	CardModeRecall      = "recall"      // meaning shown, word recalled
+	CardModeSpelling    = "spelling"    // word typed
+	CardModeListening   = "listening"   // audio played, word recalled
+	CardModeCloze       = "cloze"       // word filled into its context sentence
+)
+
+// Client types reporting reviews
+var clientTypes = map[string]bool{
+	"web":       true,
+	"ios":       true,
+	"android":   true,
+	"extension": true,
+}
+
+// MaxResponseTimeMs caps reported response times; longer ones mean the
+// user stepped away rather than took that long to answer
+const MaxResponseTimeMs = 10 * 60 * 1000
+
 // SubmitReviewRequest represents a review submission request
 type SubmitReviewRequest struct {
-	UserWordID uuid.UUID `json:"user_word_id"`
-	Quality    int       `json:"quality"` // 0-5
+	CardID uuid.UUID `json:"card_id"`
+	// UserWordID identifies the word's recognition card when CardID is not set
+	UserWordID     uuid.UUID `json:"user_word_id"`
+	Quality        int       `json:"quality"`          // 0-5
+	ResponseTimeMs *int      `json:"response_time_ms"` // Time from showing the card to answering
+	CardMode       string    `json:"card_mode"`        // Defaults to the card type
+	ClientType     string    `json:"client_type"`
 }
 
-// SubmitReview processes a review submission and updates SM-2 parameters
-func (s *ReviewService) SubmitReview(ctx context.Context, userID string, req SubmitReviewRequest) error {
-	// Validate quality range
+// Validate checks a submission and fills in defaults
+func (req *SubmitReviewRequest) Validate() error {
+	if req.CardID == uuid.Nil && req.UserWordID == uuid.Nil {
+		return fmt.Errorf("card_id or user_word_id is required")
+	}
+
 	if req.Quality < 0 || req.Quality > 5 {
 		return fmt.Errorf("quality must be between 0 and 5")
 	}
 
-	// Get current word state
-	dueWords, err := s.reviewRepo.GetDueWords(ctx, userID, 1000)
+	responseTimeMs, err := validateAnswerContext(req.ResponseTimeMs, req.ClientType)
 	if err != nil {
-		return fmt.Errorf("failed to get word state: %w", err)
+		return err
+	}
+	req.ResponseTimeMs = responseTimeMs
+
+	switch req.CardMode {
+	case "", CardModeRecognition, CardModeRecall, CardModeSpelling, CardModeListening, CardModeCloze:
+	default:
+		return fmt.Errorf("unknown card_mode: %s", req.CardMode)
 	}
 
-	// Find the word being reviewed
-	var currentWord *repository.DueWord
-	for i := range dueWords {
-		if dueWords[i].UserWordID == req.UserWordID {
-			currentWord = &dueWords[i]
-			break
+	return nil
+}
+
+// validateAnswerContext checks the response time and client reported with an
+// answer, returning the response time capped at MaxResponseTimeMs
+func validateAnswerContext(responseTimeMs *int, clientType string) (*int, error) {
+	if responseTimeMs != nil {
+		if *responseTimeMs < 0 {
+			return nil, fmt.Errorf("response_time_ms must not be negative")
+		}
+		if *responseTimeMs > MaxResponseTimeMs {
+			capped := MaxResponseTimeMs
+			responseTimeMs = &capped
 		}
 	}
 
-	if currentWord == nil {
-		return fmt.Errorf("word not found or not due for review")
+	if clientType != "" && !clientTypes[clientType] {
+		return nil, fmt.Errorf("unknown client_type: %s", clientType)
+	}
+
+	return responseTimeMs, nil
+}
+
+// SubmitReview processes a review submission and updates the card's scheduling
+// state. Typed cards must be answered through GradeTypedAnswer instead.
+func (s *ReviewService) SubmitReview(ctx context.Context, userID string, req SubmitReviewRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	currentWord, err := s.submittedCard(ctx, userID, req)
+	if err != nil {
+		return fmt.Errorf("failed to get card state: %w", err)
+	}
+	now := s.clock()
+	if currentWord == nil || !isDue(*currentWord, now) {
+		return fmt.Errorf("card not found or not due for review")
+	}
+	if isTypedCard(currentWord.CardType) {
+		return ErrTypedCard
 	}
 
-	// Calculate new SM-2 parameters
-	sm2Result := CalculateSM2(
-		req.Quality,
-		currentWord.EasinessFactor,
-		currentWord.Interval,
-		currentWord.Repetitions,
-	)
+	_, err = s.recordAnswer(ctx, userID, *currentWord, req, now)
+	return err
+}
 
-	// Create review log
-	reviewLog := repository.ReviewLog{
-		UserWordID:     req.UserWordID,
-		Quality:        req.Quality,
-		EasinessFactor: sm2Result.EasinessFactor,
-		Interval:       sm2Result.Interval,
-		Repetitions:    sm2Result.Repetitions,
-		NextReviewAt:   sm2Result.NextReviewAt,
-		ReviewedAt:     time.Now(),
+// recordAnswer schedules and records an answer to a card outside of a session
+func (s *ReviewService) recordAnswer(ctx context.Context, userID string, card repository.DueWord, req SubmitReviewRequest, now time.Time) (*SessionAnswer, error) {
+	result, sub, err := s.scheduleReview(ctx, userID, card, req, now)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.reviewRepo.CreateReviewLog(ctx, reviewLog); err != nil {
-		return fmt.Errorf("failed to create review log: %w", err)
+	recorded, err := s.reviewRepo.RecordReview(ctx, sub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record review: %w", err)
 	}
+	s.dashboard.Invalidate(userID)
 
-	// Update user word SM-2 parameters
-	if err := s.reviewRepo.UpdateUserWordSM2(
-		ctx,
-		req.UserWordID,
-		sm2Result.EasinessFactor,
-		sm2Result.Interval,
-		sm2Result.Repetitions,
-		sm2Result.NextReviewAt,
-	); err != nil {
-		return fmt.Errorf("failed to update word SM-2: %w", err)
+	return &SessionAnswer{
+		Correct:      req.Quality >= 3,
+		XP:           sub.Log.XPAwarded,
+		Interval:     result.Interval,
+		CardState:    result.State,
+		NextReviewAt: result.NextReviewAt,
+		Leech:        recorded.BecameLeech,
+		Suspended:    recorded.Suspended,
+	}, nil
+}
+
+// submittedCard loads the card a submission answers, or nil if the user has no such card
+func (s *ReviewService) submittedCard(ctx context.Context, userID string, req SubmitReviewRequest) (*repository.DueWord, error) {
+	if req.CardID != uuid.Nil {
+		return s.reviewRepo.GetReviewCard(ctx, userID, req.CardID)
 	}
+	return s.reviewRepo.GetWordCard(ctx, userID, req.UserWordID, CardTypeRecognition)
+}
 
-	return nil
+// isDue reports whether the card can be studied at now: a new card, or one
+// GetDueWords would return
+func isDue(word repository.DueWord, now time.Time) bool {
+	if word.NextReviewAt == nil || !word.NextReviewAt.After(now) {
+		return true
+	}
+	learning := word.CardState == CardStateLearning || word.CardState == CardStateRelearning
+	return learning && !word.NextReviewAt.After(now.Add(repository.LearnAheadLimit))
+}
+
+// scheduleReview schedules an answer to a card with the user's scheduler and
+// returns the result with the submission that records it
+func (s *ReviewService) scheduleReview(ctx context.Context, userID string, word repository.DueWord, req SubmitReviewRequest, now time.Time) (ScheduleResult, repository.ReviewSubmission, error) {
+	quality := req.Quality
+
+	// Schedule with the algorithm selected by the user
+	scheduler, err := s.schedulerFor(ctx, userID)
+	if err != nil {
+		return ScheduleResult{}, repository.ReviewSubmission{}, err
+	}
+
+	result := scheduler.Schedule(cardStateFromDueWord(word), quality, now)
+
+	// Spread graduated reviews so cards studied together do not all come due on the same day
+	if result.State == CardStateReview {
+		result, err = s.balanceInterval(ctx, userID, result, now)
+		if err != nil {
+			return ScheduleResult{}, repository.ReviewSubmission{}, err
+		}
+	}
+
+	cardMode := req.CardMode
+	if cardMode == "" {
+		cardMode = word.CardType
+	}
+
+	// Forgetting a card that had graduated is a lapse
+	isLapse := quality < 3 && normalizeCardState(cardStateFromDueWord(word)) == CardStateReview
+	lapses := word.Lapses
+	if isLapse {
+		lapses++
+	}
+
+	previous := word.Snapshot()
+	sub := repository.ReviewSubmission{
+		UserID: userID,
+		Log: repository.ReviewLog{
+			CardID:         word.CardID,
+			UserWordID:     word.UserWordID,
+			Quality:        quality,
+			EasinessFactor: result.EasinessFactor,
+			Interval:       result.Interval,
+			Repetitions:    result.Repetitions,
+			Stability:      result.Stability,
+			Difficulty:     result.Difficulty,
+			Scheduler:      scheduler.Name(),
+			CardState:      result.State,
+			NextReviewAt:   result.NextReviewAt,
+			ReviewedAt:     now,
+			Previous:       &previous,
+			XPAwarded:      xpForAnswer(quality),
+			ResponseTimeMs: req.ResponseTimeMs,
+			CardMode:       cardMode,
+			ClientType:     req.ClientType,
+			IsLapse:        isLapse,
+		},
+		Schedule: repository.CardSchedule{
+			EasinessFactor: result.EasinessFactor,
+			Interval:       result.Interval,
+			Repetitions:    result.Repetitions,
+			Stability:      result.Stability,
+			Difficulty:     result.Difficulty,
+			CardState:      result.State,
+			LearningStep:   result.Step,
+			LastReviewedAt: now,
+			NextReviewAt:   result.NextReviewAt,
+			Lapses:         lapses,
+		},
+	}
+
+	return result, sub, nil
+}
+
+// balanceInterval fuzzes the result's interval towards the least loaded
+// nearby day in the user's upcoming reviews
+func (s *ReviewService) balanceInterval(ctx context.Context, userID string, result ScheduleResult, now time.Time) (ScheduleResult, error) {
+	lower, upper := FuzzBounds(result.Interval)
+	if lower == upper {
+		return result, nil
+	}
+
+	dueCounts, err := s.reviewRepo.GetDueDistribution(ctx, userID, now, balanceWindow(result.Interval))
+	if err != nil {
+		return result, fmt.Errorf("failed to get due distribution: %w", err)
+	}
+
+	return applyInterval(result, s.balancer.Balance(result.Interval, dueCounts), now), nil
+}
+
+// schedulerFor returns the scheduler configured in the user's profile
+func (s *ReviewService) schedulerFor(ctx context.Context, userID string) (Scheduler, error) {
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	scheduler, err := NewUserScheduler(*settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduler, nil
+}
+
+// GetParameters returns the user's scheduler parameters: the stored fit if the
+// parameters have been optimised, otherwise the defaults scored against the history
+func (s *ReviewService) GetParameters(ctx context.Context, userID string) (*ParameterFit, error) {
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	if len(settings.SchedulerParams) > 0 {
+		var fit ParameterFit
+		if err := json.Unmarshal(settings.SchedulerParams, &fit); err != nil {
+			return nil, fmt.Errorf("invalid scheduler params: %w", err)
+		}
+		return &fit, nil
+	}
+
+	history, err := s.reviewRepo.GetReviewHistory(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review history: %w", err)
+	}
+
+	eval := evaluateSM2Params(DefaultSM2Params, *settings, history)
+	fit := &ParameterFit{
+		Params:               DefaultSM2Params,
+		ReviewCount:          eval.count,
+		LogLikelihood:        eval.logLikelihood,
+		DefaultLogLikelihood: eval.logLikelihood,
+	}
+	if eval.count > 0 {
+		fit.ExpectedRetention = eval.predicted / float64(eval.count)
+		fit.ActualRetention = float64(eval.recalled) / float64(eval.count)
+	}
+
+	return fit, nil
+}
+
+// OptimizeParameters fits SM-2 parameters to the user's review history and
+// stores them on the profile
+func (s *ReviewService) OptimizeParameters(ctx context.Context, userID string) (*ParameterFit, error) {
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	history, err := s.reviewRepo.GetReviewHistory(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review history: %w", err)
+	}
+
+	fit := FitSM2Params(*settings, history)
+	if !fit.Fitted {
+		return nil, fmt.Errorf("%w: %d of %d reviews", ErrNotEnoughHistory, fit.ReviewCount, MinFitReviews)
+	}
+
+	fittedAt := s.clock()
+	fit.FittedAt = &fittedAt
+
+	params, err := json.Marshal(fit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scheduler params: %w", err)
+	}
+
+	if err := s.reviewRepo.UpdateSchedulerParams(ctx, userID, params); err != nil {
+		return nil, fmt.Errorf("failed to store scheduler params: %w", err)
+	}
+
+	return &fit, nil
 }
 
 // GetReviewStats retrieves review statistics for a user
@@ -118,3 +388,13 @@ func (s *ReviewService) GetReviewStats(ctx context.Context, userID string) (*rep
 
 	return stats, nil
 }
+
+// ListReviewHistory returns one page of the user's reviews, newest first
+func (s *ReviewService) ListReviewHistory(ctx context.Context, userID string, query repository.ReviewHistoryQuery) (*repository.ReviewHistoryPage, error) {
+	page, err := s.reviewRepo.ListReviewHistory(ctx, userID, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review history: %w", err)
+	}
+
+	return page, nil
+}
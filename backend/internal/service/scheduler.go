@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"vocabweb/internal/repository"
+)
+
+// DefaultDesiredRetention is the recall probability intervals are scheduled for
+// when the user has not chosen one, matching the profiles column default
+const DefaultDesiredRetention = 0.9
+
+// Allowed desired retention range, matching the profiles check constraint
+const (
+	MinDesiredRetention = 0.70
+	MaxDesiredRetention = 0.99
+)
+
+// Supported scheduler names, as stored in profiles.scheduler
+const (
+	SchedulerSM2  = "sm2"
+	SchedulerFSRS = "fsrs"
+)
+
+// CardState is the scheduling state of a word before a review
+type CardState struct {
+	EasinessFactor float64
+	Interval       int
+	Repetitions    int
+	Stability      float64
+	Difficulty     float64
+	State          string
+	Step           int
+	LastReviewedAt *time.Time
+}
+
+// ScheduleResult is the scheduling state of a word after a review
+type ScheduleResult struct {
+	EasinessFactor float64
+	Interval       int
+	Repetitions    int
+	Stability      float64
+	Difficulty     float64
+	State          string
+	Step           int
+	NextReviewAt   time.Time
+}
+
+// Scheduler computes the next review of a word from its current state
+// and the quality (0-5) of the answer given at time now.
+//
+// Every implementation keeps both the SM-2 fields and the memory state
+// (stability, difficulty) up to date, so a user can switch algorithms
+// without losing their history.
+type Scheduler interface {
+	Name() string
+	Schedule(state CardState, quality int, now time.Time) ScheduleResult
+}
+
+// NewScheduler returns the scheduler registered under name
+func NewScheduler(name string) (Scheduler, error) {
+	switch name {
+	case SchedulerSM2, "":
+		return NewSM2Scheduler(), nil
+	case SchedulerFSRS:
+		return NewFSRSScheduler(), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler: %s", name)
+	}
+}
+
+// NewUserScheduler builds the scheduler described by a user's profile settings:
+// the selected algorithm, tuned to the user's desired retention and fitted
+// parameters, wrapped in the user's learning and relearning steps
+func NewUserScheduler(settings repository.SchedulerSettings) (Scheduler, error) {
+	scheduler, err := NewScheduler(settings.Scheduler)
+	if err != nil {
+		return nil, err
+	}
+
+	retention := settings.DesiredRetention
+	if retention <= 0 {
+		retention = DefaultDesiredRetention
+	}
+
+	switch sched := scheduler.(type) {
+	case *SM2Scheduler:
+		sched.DesiredRetention = retention
+
+		// Apply personal SM-2 parameters once they have been fitted
+		if len(settings.SchedulerParams) > 0 {
+			var fit ParameterFit
+			if err := json.Unmarshal(settings.SchedulerParams, &fit); err != nil {
+				return nil, fmt.Errorf("invalid scheduler params: %w", err)
+			}
+			if fit.Fitted {
+				sched.Params = fit.Params
+			}
+		}
+	case *FSRSScheduler:
+		sched.RequestRetention = retention
+	}
+
+	return NewStepScheduler(scheduler, settings.LearningSteps, settings.RelearningSteps), nil
+}
+
+// ValidateRetention checks a desired retention value
+func ValidateRetention(retention float64) error {
+	if retention < MinDesiredRetention || retention > MaxDesiredRetention {
+		return fmt.Errorf("desired retention must be between %.2f and %.2f", MinDesiredRetention, MaxDesiredRetention)
+	}
+	return nil
+}
+
+// clampQuality limits quality to the 0-5 range
+func clampQuality(quality int) int {
+	if quality < 0 {
+		return 0
+	}
+	if quality > 5 {
+		return 5
+	}
+	return quality
+}
+
+// difficultyFromEasiness maps an SM-2 easiness factor onto the FSRS
+// difficulty scale: EF 2.5 is an average card (5), EF 1.3 the hardest (10)
+func difficultyFromEasiness(ef float64) float64 {
+	return math.Min(10, math.Max(1, 5+(2.5-ef)*5/1.2))
+}
+
+// addDays returns now plus a whole number of days
+func addDays(now time.Time, days int) time.Time {
+	return now.Add(time.Duration(days) * 24 * time.Hour)
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Session size limits, in cards
+const (
+	DefaultSessionSize = 20
+	MaxSessionSize     = 100
+)
+
+// maxSessionQueue bounds how long a session can grow by re-queuing failed cards
+const maxSessionQueue = 3 * MaxSessionSize
+
+// XP awarded per answer
+const (
+	XPCorrectAnswer   = 10
+	XPIncorrectAnswer = 2
+)
+
+// Review session errors
+var (
+	ErrSessionNotFound   = errors.New("review session not found")
+	ErrSessionCompleted  = errors.New("review session is already completed")
+	ErrNotCurrentCard    = errors.New("card is not the current card of the session")
+	ErrReviewWordMissing = errors.New("card not found")
+)
+
+// CreateSessionRequest represents a request to start a review session
+type CreateSessionRequest struct {
+	Size int `json:"size"`
+	// GroupID limits the session to the words of one group, and TagQuery to
+	// words matching a boolean tag query such as `tag:gre AND NOT tag:done`
+	GroupID  *uuid.UUID `json:"group_id"`
+	TagQuery string     `json:"tag_query"`
+}
+
+// Validate checks the request's tag query
+func (req *CreateSessionRequest) Validate() error {
+	return req.filter().Validate()
+}
+
+// filter selects the words the session's cards are drawn from
+func (req *CreateSessionRequest) filter() repository.WordFilter {
+	return repository.WordFilter{GroupID: req.GroupID, TagQuery: req.TagQuery}
+}
+
+// SessionCard is the next card to show in a session
+type SessionCard struct {
+	SessionID uuid.UUID  `json:"session_id"`
+	Position  int        `json:"position"`
+	Remaining int        `json:"remaining"`
+	Card      ReviewCard `json:"card"`
+}
+
+// SessionSummary describes the progress or outcome of a session
+type SessionSummary struct {
+	SessionID        uuid.UUID  `json:"session_id"`
+	Status           string     `json:"status"`
+	TotalCards       int        `json:"total_cards"`
+	NewCards         int        `json:"new_cards"`
+	Remaining        int        `json:"remaining"`
+	Answered         int        `json:"answered"`
+	Correct          int        `json:"correct"`
+	Accuracy         float64    `json:"accuracy"`
+	TimeSpentSeconds int        `json:"time_spent_seconds"`
+	XPEarned         int        `json:"xp_earned"`
+	StartedAt        time.Time  `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+}
+
+// SessionAnswer is the outcome of answering a card; Summary is only set for
+// cards answered in a session
+type SessionAnswer struct {
+	Correct      bool      `json:"correct"`
+	XP           int       `json:"xp"`
+	Interval     int       `json:"interval"`
+	CardState    string    `json:"card_state"`
+	NextReviewAt time.Time `json:"next_review_at"`
+	// Leech is set when the answer made the word a leech, and Suspended
+	// when the word was suspended because of it
+	Leech     bool            `json:"leech,omitempty"`
+	Suspended bool            `json:"suspended,omitempty"`
+	Summary   *SessionSummary `json:"summary,omitempty"`
+}
+
+// CreateSession builds a review session from the user's due and new cards,
+// honouring what is left of today's review and new card limits and placing
+// new cards among the reviews in the user's chosen order, optionally from
+// one group's or some tags' words only. Each word
+// contributes at most one card so that its siblings are left for later
+// sessions.
+func (s *ReviewService) CreateSession(ctx context.Context, userID string, req CreateSessionRequest) (*SessionSummary, error) {
+	size := req.Size
+	if size <= 0 {
+		size = DefaultSessionSize
+	}
+	if size > MaxSessionSize {
+		size = MaxSessionSize
+	}
+
+	cards, newCards, err := s.studyCards(ctx, userID, size, req.filter())
+	if err != nil {
+		return nil, err
+	}
+
+	cardIDs := make([]uuid.UUID, len(cards))
+	for i, card := range cards {
+		cardIDs[i] = card.CardID
+	}
+
+	session, err := s.reviewRepo.CreateReviewSession(ctx, userID, cardIDs, newCards, s.clock())
+	if err != nil {
+		return nil, err
+	}
+
+	return summarizeSession(session), nil
+}
+
+// NextCard returns the card to show next, or nil once the session is completed
+func (s *ReviewService) NextCard(ctx context.Context, userID string, sessionID uuid.UUID) (*SessionCard, error) {
+	session, card, err := s.currentCard(ctx, userID, sessionID)
+	if errors.Is(err, ErrSessionCompleted) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionCard{
+		SessionID: session.ID,
+		Position:  session.Position,
+		Remaining: len(session.CardIDs) - session.Position,
+		Card:      NewReviewCard(*card),
+	}, nil
+}
+
+// SubmitSessionAnswer records the answer to the session's current card and
+// advances the session. Cards that are still in their learning steps after
+// the answer are queued again at the end of the session, and shown once
+// their step is due. Typed cards are
+// graded by the server, so their quality cannot be submitted here.
+func (s *ReviewService) SubmitSessionAnswer(ctx context.Context, userID string, sessionID uuid.UUID, req SubmitReviewRequest) (*SessionAnswer, error) {
+	return s.submitSessionAnswer(ctx, userID, sessionID, req, false)
+}
+
+// submitSessionAnswer records an answer to the session's current card, whose
+// quality was graded by the server if graded is set
+func (s *ReviewService) submitSessionAnswer(ctx context.Context, userID string, sessionID uuid.UUID, req SubmitReviewRequest, graded bool) (*SessionAnswer, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	session, card, err := s.currentCard(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.CardID != uuid.Nil && card.CardID != req.CardID {
+		return nil, ErrNotCurrentCard
+	}
+	// Clients that only send the word answer whichever of its cards is current
+	if req.CardID == uuid.Nil && card.UserWordID != req.UserWordID {
+		return nil, ErrNotCurrentCard
+	}
+	if !graded && isTypedCard(card.CardType) {
+		return nil, ErrTypedCard
+	}
+
+	now := s.clock()
+	result, sub, err := s.scheduleReview(ctx, userID, *card, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	correct := req.Quality >= 3
+	session.Position++
+	session.Answered++
+	if correct {
+		session.Correct++
+	}
+	session.XPEarned += sub.Log.XPAwarded
+	session.LastAnsweredAt = &now
+
+	if isLearningState(result.State) && len(session.CardIDs) < maxSessionQueue {
+		session.CardIDs = append(session.CardIDs, card.CardID)
+		sub.Log.Requeued = true
+	}
+	if session.Position >= len(session.CardIDs) {
+		session.Status = repository.SessionStatusCompleted
+		session.CompletedAt = &now
+	}
+
+	sub.Log.SessionID = &session.ID
+	sub.Session = session
+	sub.SessionPosition = session.Position - 1
+	recorded, err := s.reviewRepo.RecordReview(ctx, sub)
+	if errors.Is(err, repository.ErrSessionChanged) {
+		// Another answer to the same card was recorded first
+		return nil, ErrNotCurrentCard
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record review: %w", err)
+	}
+	s.dashboard.Invalidate(userID)
+
+	return &SessionAnswer{
+		Correct:      correct,
+		XP:           sub.Log.XPAwarded,
+		Interval:     result.Interval,
+		CardState:    result.State,
+		NextReviewAt: result.NextReviewAt,
+		Leech:        recorded.BecameLeech,
+		Suspended:    recorded.Suspended,
+		Summary:      summarizeSession(session),
+	}, nil
+}
+
+// GetSessionSummary returns the progress of a session, or its final results
+// once it is completed
+func (s *ReviewService) GetSessionSummary(ctx context.Context, userID string, sessionID uuid.UUID) (*SessionSummary, error) {
+	session, err := s.reviewRepo.GetReviewSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	return summarizeSession(session), nil
+}
+
+// maxQueueAttempts bounds how often the current card of a session is looked
+// up again when another request moved the session on in the meantime
+const maxQueueAttempts = 3
+
+// currentCard loads an active session and the card to show at its position.
+// Skipped and deferred cards are written back to the session's queue, and the
+// session is completed if no card is left to show.
+func (s *ReviewService) currentCard(ctx context.Context, userID string, sessionID uuid.UUID) (*repository.ReviewSession, *repository.DueWord, error) {
+	for attempt := 0; attempt < maxQueueAttempts; attempt++ {
+		session, err := s.activeSession(ctx, userID, sessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		remaining := session.CardIDs[session.Position:]
+		cards, err := s.reviewRepo.GetSessionCards(ctx, userID, remaining)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		now := s.clock()
+		queue, skipped, card := nextSessionCard(remaining, cards, now)
+		if skipped == 0 && card != nil && card.CardID == remaining[0] {
+			return session, card, nil
+		}
+
+		fromPosition := session.Position
+		session.CardIDs = append(session.CardIDs[:fromPosition:fromPosition], queue...)
+		session.Position += skipped
+		if card == nil {
+			session.Status = repository.SessionStatusCompleted
+			session.CompletedAt = &now
+		}
+		err = s.reviewRepo.UpdateSessionQueue(ctx, session, fromPosition)
+		if errors.Is(err, repository.ErrSessionChanged) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if card == nil {
+			return nil, nil, ErrSessionCompleted
+		}
+		return session, card, nil
+	}
+
+	return nil, nil, ErrNotCurrentCard
+}
+
+// nextSessionCard picks the card to show from the cards left in a session's
+// queue, given those that can still be reviewed. Cards whose word was
+// deleted, suspended or buried since the session started are skipped, and
+// learning cards whose step is not due yet are moved behind the next card
+// that is. Once only such learning cards are left, the one due first is shown
+// if it is due within the learn ahead limit; later ones wait for another
+// session. It returns the reordered queue, the number of cards skipped at its
+// front and the card to show, which is nil if none is left.
+func nextSessionCard(queue []uuid.UUID, cards map[uuid.UUID]repository.DueWord, now time.Time) ([]uuid.UUID, int, *repository.DueWord) {
+	var skipped, waiting []uuid.UUID
+	for i, id := range queue {
+		card, ok := cards[id]
+		if !ok {
+			skipped = append(skipped, id)
+			continue
+		}
+		if isLearningState(card.CardState) && card.NextReviewAt != nil && card.NextReviewAt.After(now) {
+			waiting = append(waiting, id)
+			continue
+		}
+
+		reordered := make([]uuid.UUID, 0, len(queue))
+		reordered = append(reordered, skipped...)
+		reordered = append(reordered, id)
+		reordered = append(reordered, waiting...)
+		reordered = append(reordered, queue[i+1:]...)
+		return reordered, len(skipped), &card
+	}
+
+	first := -1
+	for i, id := range waiting {
+		if first < 0 || cards[id].NextReviewAt.Before(*cards[waiting[first]].NextReviewAt) {
+			first = i
+		}
+	}
+	if first < 0 || cards[waiting[first]].NextReviewAt.After(now.Add(repository.LearnAheadLimit)) {
+		// Nothing can be shown in this session any more: skip the whole queue
+		return append(skipped, waiting...), len(queue), nil
+	}
+
+	card := cards[waiting[first]]
+	reordered := make([]uuid.UUID, 0, len(queue))
+	reordered = append(reordered, skipped...)
+	reordered = append(reordered, waiting[first])
+	reordered = append(reordered, waiting[:first]...)
+	reordered = append(reordered, waiting[first+1:]...)
+	return reordered, len(skipped), &card
+}
+
+// isLearningState reports whether a card in the given state is in its
+// learning or relearning steps
+func isLearningState(state string) bool {
+	return state == CardStateLearning || state == CardStateRelearning
+}
+
+// activeSession loads a session that still has cards to answer
+func (s *ReviewService) activeSession(ctx context.Context, userID string, sessionID uuid.UUID) (*repository.ReviewSession, error) {
+	session, err := s.reviewRepo.GetReviewSession(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	if session.Status != repository.SessionStatusActive || session.Position >= len(session.CardIDs) {
+		return nil, ErrSessionCompleted
+	}
+	return session, nil
+}
+
+// summarizeSession computes a session's accuracy and time spent. Time runs
+// from the start of the session to its last answer.
+func summarizeSession(session *repository.ReviewSession) *SessionSummary {
+	summary := &SessionSummary{
+		SessionID:   session.ID,
+		Status:      session.Status,
+		TotalCards:  len(session.CardIDs),
+		NewCards:    session.NewCards,
+		Remaining:   len(session.CardIDs) - session.Position,
+		Answered:    session.Answered,
+		Correct:     session.Correct,
+		XPEarned:    session.XPEarned,
+		StartedAt:   session.StartedAt,
+		CompletedAt: session.CompletedAt,
+	}
+	if summary.Remaining < 0 {
+		summary.Remaining = 0
+	}
+	if session.Answered > 0 {
+		summary.Accuracy = float64(session.Correct) / float64(session.Answered)
+	}
+	if session.LastAnsweredAt != nil {
+		summary.TimeSpentSeconds = int(session.LastAnsweredAt.Sub(session.StartedAt).Seconds())
+	}
+
+	return summary
+}
+
+// xpForAnswer is the XP earned for an answer of the given quality
+func xpForAnswer(quality int) int {
+	if quality >= 3 {
+		return XPCorrectAnswer
+	}
+	return XPIncorrectAnswer
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
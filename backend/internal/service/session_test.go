@@ -0,0 +1,113 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestNextSessionCard(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	review := func(id uuid.UUID) repository.DueWord {
+		due := now.Add(-time.Hour)
+		return repository.DueWord{CardID: id, CardState: CardStateReview, NextReviewAt: &due}
+	}
+	learning := func(id uuid.UUID, in time.Duration) repository.DueWord {
+		due := now.Add(in)
+		return repository.DueWord{CardID: id, CardState: CardStateLearning, NextReviewAt: &due}
+	}
+	cardsOf := func(words ...repository.DueWord) map[uuid.UUID]repository.DueWord {
+		cards := make(map[uuid.UUID]repository.DueWord)
+		for _, word := range words {
+			cards[word.CardID] = word
+		}
+		return cards
+	}
+
+	tests := []struct {
+		name        string
+		queue       []uuid.UUID
+		cards       map[uuid.UUID]repository.DueWord
+		wantQueue   []uuid.UUID
+		wantSkipped int
+		wantCard    *uuid.UUID
+	}{
+		{
+			name:      "current card is due",
+			queue:     []uuid.UUID{a, b},
+			cards:     cardsOf(review(a), review(b)),
+			wantQueue: []uuid.UUID{a, b},
+			wantCard:  &a,
+		},
+		{
+			name:        "deleted, suspended or buried cards are skipped",
+			queue:       []uuid.UUID{a, b, c},
+			cards:       cardsOf(review(c)),
+			wantQueue:   []uuid.UUID{a, b, c},
+			wantSkipped: 2,
+			wantCard:    &c,
+		},
+		{
+			name:      "learning card due later moves behind a due card",
+			queue:     []uuid.UUID{a, b, c},
+			cards:     cardsOf(learning(a, 5*time.Minute), review(b), review(c)),
+			wantQueue: []uuid.UUID{b, a, c},
+			wantCard:  &b,
+		},
+		{
+			name:      "learning card that is due is shown",
+			queue:     []uuid.UUID{a, b},
+			cards:     cardsOf(learning(a, -time.Minute), review(b)),
+			wantQueue: []uuid.UUID{a, b},
+			wantCard:  &a,
+		},
+		{
+			name:        "only learning cards left shows the one due first",
+			queue:       []uuid.UUID{a, b, c, d},
+			cards:       cardsOf(learning(b, 10*time.Minute), learning(c, 2*time.Minute), learning(d, 5*time.Minute)),
+			wantQueue:   []uuid.UUID{a, c, b, d},
+			wantSkipped: 1,
+			wantCard:    &c,
+		},
+		{
+			name:        "learning cards beyond the learn ahead limit wait for another session",
+			queue:       []uuid.UUID{a, b},
+			cards:       cardsOf(learning(b, time.Hour)),
+			wantQueue:   []uuid.UUID{a, b},
+			wantSkipped: 2,
+		},
+		{
+			name:        "no card left",
+			queue:       []uuid.UUID{a},
+			cards:       cardsOf(),
+			wantQueue:   []uuid.UUID{a},
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue, skipped, card := nextSessionCard(tt.queue, tt.cards, now)
+			if !reflect.DeepEqual(queue, tt.wantQueue) {
+				t.Errorf("queue = %v, want %v", queue, tt.wantQueue)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+			switch {
+			case tt.wantCard == nil && card != nil:
+				t.Errorf("card = %v, want none", card.CardID)
+			case tt.wantCard != nil && card == nil:
+				t.Errorf("no card, want %v", *tt.wantCard)
+			case tt.wantCard != nil && card.CardID != *tt.wantCard:
+				t.Errorf("card = %v, want %v", card.CardID, *tt.wantCard)
+			}
+		})
+	}
+}
@@ -14,7 +14,7 @@ type SM2Result struct {
 }
 
 // CalculateSM2 implements the SuperMemo 2 (SM-2) spaced repetition algorithm
-// 
+//
 // Parameters:
 //   - quality: User's recall quality (0-5)
 //     0-2: Incorrect response (don't recognize)
@@ -27,22 +27,32 @@ type SM2Result struct {
 // Returns:
 //   - SM2Result with updated values and next review timestamp
 func CalculateSM2(quality int, easinessFactor float64, interval int, repetitions int) SM2Result {
+	return calculateSM2At(DefaultSM2Params, quality, easinessFactor, interval, repetitions, time.Now())
+}
+
+// SM2Params are the tunable constants of SM-2
+type SM2Params struct {
+	InitialEasiness float64 `json:"initial_easiness"`
+	MinEasiness     float64 `json:"min_easiness"`
+	FirstInterval   int     `json:"first_interval"`
+	SecondInterval  int     `json:"second_interval"`
+}
+
+// DefaultSM2Params are the constants of the original SM-2 algorithm
+var DefaultSM2Params = SM2Params{
+	InitialEasiness: 2.5,
+	MinEasiness:     1.3,
+	FirstInterval:   1,
+	SecondInterval:  6,
+}
+
+// calculateSM2At is CalculateSM2 with the given parameters, evaluated at the given time
+func calculateSM2At(params SM2Params, quality int, easinessFactor float64, interval int, repetitions int, now time.Time) SM2Result {
 	// Validate quality range
-	if quality < 0 {
-		quality = 0
-	}
-	if quality > 5 {
-		quality = 5
-	}
+	quality = clampQuality(quality)
 
 	// Calculate new easiness factor
-	// Formula: EF' = EF + (0.1 - (5-q) * (0.08 + (5-q) * 0.02))
-	newEF := easinessFactor + (0.1 - float64(5-quality)*(0.08+float64(5-quality)*0.02))
-	
-	// Ensure EF doesn't go below 1.3
-	if newEF < 1.3 {
-		newEF = 1.3
-	}
+	newEF := math.Max(nextEasiness(easinessFactor, quality), params.MinEasiness)
 
 	var newInterval int
 	var newRepetitions int
@@ -50,7 +60,7 @@ func CalculateSM2(quality int, easinessFactor float64, interval int, repetitions
 	// If quality < 3, reset the learning process
 	if quality < 3 {
 		newRepetitions = 0
-		newInterval = 1
+		newInterval = params.FirstInterval
 	} else {
 		// Correct response (quality >= 3)
 		newRepetitions = repetitions + 1
@@ -58,23 +68,100 @@ func CalculateSM2(quality int, easinessFactor float64, interval int, repetitions
 		switch newRepetitions {
 		case 1:
 			// First correct review
-			newInterval = 1
+			newInterval = params.FirstInterval
 		case 2:
 			// Second correct review
-			newInterval = 6
+			newInterval = params.SecondInterval
 		default:
 			// Subsequent reviews: multiply previous interval by EF
 			newInterval = int(math.Round(float64(interval) * newEF))
 		}
 	}
 
-	// Calculate next review timestamp
-	nextReviewAt := time.Now().Add(time.Duration(newInterval) * 24 * time.Hour)
-
 	return SM2Result{
 		EasinessFactor: newEF,
 		Interval:       newInterval,
 		Repetitions:    newRepetitions,
-		NextReviewAt:   nextReviewAt,
+		NextReviewAt:   addDays(now, newInterval),
+	}
+}
+
+// nextEasiness applies the SM-2 easiness factor update for a quality 0-5
+func nextEasiness(easinessFactor float64, quality int) float64 {
+	// Formula: EF' = EF + (0.1 - (5-q) * (0.08 + (5-q) * 0.02))
+	newEF := easinessFactor + (0.1 - float64(5-quality)*(0.08+float64(5-quality)*0.02))
+
+	// Ensure EF doesn't go below 1.3
+	if newEF < 1.3 {
+		newEF = 1.3
+	}
+	return newEF
+}
+
+// SM2Scheduler is the Scheduler implementation of SM-2.
+//
+// SM-2 intervals are treated as the point at which a word is recalled with
+// 90% probability (its stability). The EF multiplication grows the stability,
+// and the interval actually scheduled is the time at which recall falls to
+// DesiredRetention under SM2Retrievability.
+type SM2Scheduler struct {
+	Params           SM2Params
+	DesiredRetention float64
+}
+
+// NewSM2Scheduler creates a new SM-2 scheduler with the default parameters
+func NewSM2Scheduler() *SM2Scheduler {
+	return &SM2Scheduler{
+		Params:           DefaultSM2Params,
+		DesiredRetention: DefaultDesiredRetention,
+	}
+}
+
+// Name returns the scheduler name stored on profiles and review logs
+func (s *SM2Scheduler) Name() string {
+	return SchedulerSM2
+}
+
+// Schedule applies SM-2 to the card's stability and converts the new
+// stability into an interval that hits the desired retention
+func (s *SM2Scheduler) Schedule(state CardState, quality int, now time.Time) ScheduleResult {
+	ef := state.EasinessFactor
+	if ef == 0 || state.LastReviewedAt == nil {
+		// First review of a new word
+		ef = s.Params.InitialEasiness
+	}
+
+	// Words scheduled before stability was tracked grow from their interval
+	stability := int(math.Round(state.Stability))
+	if stability <= 0 {
+		stability = state.Interval
+	}
+
+	result := calculateSM2At(s.Params, quality, ef, stability, state.Repetitions, now)
+	interval := retentionInterval(float64(result.Interval), s.DesiredRetention)
+
+	return ScheduleResult{
+		EasinessFactor: result.EasinessFactor,
+		Interval:       interval,
+		Repetitions:    result.Repetitions,
+		Stability:      float64(result.Interval),
+		Difficulty:     difficultyFromEasiness(result.EasinessFactor),
+		State:          CardStateReview,
+		NextReviewAt:   addDays(now, interval),
+	}
+}
+
+// retentionInterval returns the whole number of days after which a word with
+// the given stability is recalled with probability retention under
+// SM2Retrievability. At 90% retention the interval equals the stability.
+func retentionInterval(stability, retention float64) int {
+	if retention <= 0 || retention >= 1 {
+		retention = DefaultDesiredRetention
+	}
+
+	days := int(math.Round(stability * math.Log(retention) / math.Log(0.9)))
+	if days < 1 {
+		days = 1
 	}
+	return days
 }
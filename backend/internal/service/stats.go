@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"vocabweb/internal/repository"
+)
+
+// Retention analysis window limits in days
+const (
+	DefaultRetentionDays = 90
+	MaxRetentionDays     = 3650
+)
+
+// Years the activity calendar can be requested for
+const (
+	MinCalendarYear = 2000
+	MaxCalendarYear = 2100
+)
+
+// calibrationBins is the number of equal-width predicted recall bins
+const calibrationBins = 10
+
+// retentionIntervalBuckets group reviews by the card's interval before the
+// review, in days; the last bucket has no upper bound
+var retentionIntervalBuckets = []struct {
+	label    string
+	min, max int
+}{
+	{"1d", 0, 1},
+	{"2-3d", 2, 3},
+	{"4-7d", 4, 7},
+	{"1-2w", 8, 14},
+	{"2w-1m", 15, 30},
+	{"1-3m", 31, 90},
+	{"3-6m", 91, 180},
+	{"6m+", 181, 0},
+}
+
+// StatsService computes learning statistics from the review history
+type StatsService struct {
+	statsRepo  *repository.StatsRepository
+	reviewRepo *repository.ReviewRepository
+	clock      func() time.Time
+}
+
+// NewStatsService creates a new stats service instance
+func NewStatsService(statsRepo *repository.StatsRepository, reviewRepo *repository.ReviewRepository) *StatsService {
+	return &StatsService{
+		statsRepo:  statsRepo,
+		reviewRepo: reviewRepo,
+		clock:      time.Now,
+	}
+}
+
+// RetentionRate is the share of reviews of graduated cards that were passed
+type RetentionRate struct {
+	Reviews int     `json:"reviews"`
+	Passed  int     `json:"passed"`
+	Rate    float64 `json:"rate"`
+}
+
+func (r *RetentionRate) add(passed bool) {
+	r.Reviews++
+	if passed {
+		r.Passed++
+	}
+	r.Rate = float64(r.Passed) / float64(r.Reviews)
+}
+
+// IntervalRetention is the retention of reviews made at one range of intervals;
+// MaxDays is 0 for the open-ended last range
+type IntervalRetention struct {
+	Label   string `json:"label"`
+	MinDays int    `json:"min_days"`
+	MaxDays int    `json:"max_days"`
+	RetentionRate
+}
+
+// LapsePeriod is the share of reviews of graduated cards that were lapses in
+// the study week starting on WeekStart (a Monday)
+type LapsePeriod struct {
+	WeekStart string  `json:"week_start"`
+	Reviews   int     `json:"reviews"`
+	Lapses    int     `json:"lapses"`
+	Rate      float64 `json:"rate"`
+}
+
+// CalibrationBin compares the recall the scheduler predicted with the recall
+// observed, for reviews whose prediction fell in [Min, Max)
+type CalibrationBin struct {
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	Reviews   int     `json:"reviews"`
+	Predicted float64 `json:"predicted"`
+	Actual    float64 `json:"actual"`
+}
+
+// RetentionStats shows whether the scheduler achieves the retention the user asked for
+type RetentionStats struct {
+	Days             int     `json:"days"`
+	DesiredRetention float64 `json:"desired_retention"`
+	MatureInterval   int     `json:"mature_interval"`
+	// TrueRetention is the pass rate on mature cards, YoungRetention on the
+	// other graduated cards
+	TrueRetention  RetentionRate       `json:"true_retention"`
+	YoungRetention RetentionRate       `json:"young_retention"`
+	ByInterval     []IntervalRetention `json:"by_interval"`
+	// EaseDistribution is of current cards; it is only meaningful under SM-2
+	EaseDistribution []repository.EaseCount `json:"ease_distribution"`
+	LapseRate        []LapsePeriod          `json:"lapse_rate"`
+	Calibration      []CalibrationBin       `json:"calibration"`
+	// CalibrationError is the review-weighted mean gap between predicted and
+	// actual recall across the calibration bins
+	CalibrationError float64 `json:"calibration_error"`
+}
+
+// GetRetentionStats analyses the user's reviews of graduated cards over the
+// last days
+func (s *StatsService) GetRetentionStats(ctx context.Context, userID string, days int) (*RetentionStats, error) {
+	if days <= 0 {
+		days = DefaultRetentionDays
+	}
+	if days > MaxRetentionDays {
+		days = MaxRetentionDays
+	}
+
+	settings, err := s.reviewRepo.GetSchedulerSettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler settings: %w", err)
+	}
+
+	reviews, err := s.statsRepo.GetGradedReviews(ctx, userID, addDays(s.clock(), -days))
+	if err != nil {
+		return nil, err
+	}
+
+	ease, err := s.statsRepo.GetEaseDistribution(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &RetentionStats{
+		Days:             days,
+		DesiredRetention: settings.DesiredRetention,
+		MatureInterval:   repository.MatureInterval,
+		ByInterval:       make([]IntervalRetention, len(retentionIntervalBuckets)),
+		EaseDistribution: ease,
+		LapseRate:        []LapsePeriod{},
+	}
+	for i, bucket := range retentionIntervalBuckets {
+		stats.ByInterval[i] = IntervalRetention{Label: bucket.label, MinDays: bucket.min, MaxDays: bucket.max}
+	}
+
+	bins := make([]CalibrationBin, calibrationBins)
+	for i := range bins {
+		bins[i].Min = float64(i) / calibrationBins
+		bins[i].Max = float64(i+1) / calibrationBins
+	}
+
+	for _, review := range reviews {
+		if review.PrevInterval >= repository.MatureInterval {
+			stats.TrueRetention.add(review.Passed)
+		} else {
+			stats.YoungRetention.add(review.Passed)
+		}
+
+		stats.ByInterval[intervalBucket(review.PrevInterval)].add(review.Passed)
+
+		// Reviews are oldest first, so a new week is always the last one
+		week := review.StudyDate.AddDate(0, 0, -(int(review.StudyDate.Weekday())+6)%7).Format("2006-01-02")
+		if n := len(stats.LapseRate); n == 0 || stats.LapseRate[n-1].WeekStart != week {
+			stats.LapseRate = append(stats.LapseRate, LapsePeriod{WeekStart: week})
+		}
+		period := &stats.LapseRate[len(stats.LapseRate)-1]
+		period.Reviews++
+		if review.IsLapse {
+			period.Lapses++
+		}
+		period.Rate = float64(period.Lapses) / float64(period.Reviews)
+
+		if review.PrevStability > 0 {
+			predicted := predictedRecall(review.Scheduler, review.ElapsedDays, review.PrevStability)
+			bin := &bins[minInt(int(predicted*calibrationBins), calibrationBins-1)]
+			bin.Reviews++
+			bin.Predicted += predicted
+			if review.Passed {
+				bin.Actual++
+			}
+		}
+	}
+
+	stats.Calibration = []CalibrationBin{}
+	var calibrated int
+	for _, bin := range bins {
+		if bin.Reviews == 0 {
+			continue
+		}
+		bin.Predicted /= float64(bin.Reviews)
+		bin.Actual /= float64(bin.Reviews)
+		stats.Calibration = append(stats.Calibration, bin)
+		stats.CalibrationError += math.Abs(bin.Predicted-bin.Actual) * float64(bin.Reviews)
+		calibrated += bin.Reviews
+	}
+	if calibrated > 0 {
+		stats.CalibrationError /= float64(calibrated)
+	}
+
+	return stats, nil
+}
+
+// intervalBucket returns the index of the retentionIntervalBuckets entry
+// containing interval
+func intervalBucket(interval int) int {
+	for i, bucket := range retentionIntervalBuckets {
+		if bucket.max == 0 || interval <= bucket.max {
+			return i
+		}
+	}
+	return len(retentionIntervalBuckets) - 1
+}
+
+// predictedRecall is the recall probability the scheduler that set a card's
+// stability assumed after elapsedDays
+func predictedRecall(schedulerName string, elapsedDays, stability float64) float64 {
+	if schedulerName == SchedulerFSRS {
+		return FSRSRetrievability(elapsedDays, stability)
+	}
+	return SM2Retrievability(elapsedDays, stability)
+}
+
+// CalendarDay is one day of the activity calendar
+type CalendarDay struct {
+	Date             string `json:"date"`
+	Reviews          int    `json:"reviews"`
+	NewWords         int    `json:"new_words"`
+	TimeSpentSeconds int    `json:"time_spent_seconds"`
+	GoalMet          bool   `json:"goal_met"`
+}
+
+// ActivityCalendar is a user's daily activity over one calendar year
+type ActivityCalendar struct {
+	Year         int           `json:"year"`
+	TotalReviews int           `json:"total_reviews"`
+	ActiveDays   int           `json:"active_days"`
+	GoalDays     int           `json:"goal_days"`
+	Days         []CalendarDay `json:"days"`
+}
+
+// GetActivityCalendar returns an entry for every day of year, counted in the
+// user's study days; days after today are empty. A day meets the goal when
+// its reviews reach the review goal in force that day. Year 0 is the year of
+// the user's current study day.
+func (s *StatsService) GetActivityCalendar(ctx context.Context, userID string, year int) (*ActivityCalendar, error) {
+	if year == 0 {
+		today, err := s.statsRepo.GetStudyDate(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		year = today.Year()
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, -1)
+
+	stats, err := s.statsRepo.GetDailyStats(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ActivityCalendar{Year: year, Days: []CalendarDay{}}
+	next := 0
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := CalendarDay{Date: date.Format("2006-01-02")}
+		if next < len(stats) && stats[next].Date.Equal(date) {
+			stat := stats[next]
+			next++
+			day.Reviews = stat.Reviewed
+			day.NewWords = stat.NewWords
+			day.TimeSpentSeconds = stat.TimeSpentSeconds
+			day.GoalMet = stat.ReviewGoal != nil && stat.Reviewed >= *stat.ReviewGoal
+		}
+
+		calendar.TotalReviews += day.Reviews
+		if day.Reviews > 0 || day.NewWords > 0 {
+			calendar.ActiveDays++
+		}
+		if day.GoalMet {
+			calendar.GoalDays++
+		}
+		calendar.Days = append(calendar.Days, day)
+	}
+
+	return calendar, nil
+}
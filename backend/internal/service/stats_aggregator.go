@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"vocabweb/internal/repository"
+)
+
+// DefaultAggregateInterval is how often the aggregator rolls up recent activity
+const DefaultAggregateInterval = time.Minute
+
+// recentRollupDays is how many study days, ending today, are rolled up for
+// users with new activity: yesterday is included so that the last reviews
+// before a user's day rolled over are counted
+const recentRollupDays = 2
+
+// StatsAggregator keeps daily_stats current by rolling up users' activity
+// per study day in their time zone
+type StatsAggregator struct {
+	statsRepo *repository.StatsRepository
+	interval  time.Duration
+	lastRun   time.Time
+	clock     func() time.Time
+}
+
+// NewStatsAggregator creates an aggregator rolling up every interval
+func NewStatsAggregator(statsRepo *repository.StatsRepository, interval time.Duration) *StatsAggregator {
+	if interval <= 0 {
+		interval = DefaultAggregateInterval
+	}
+	return &StatsAggregator{
+		statsRepo: statsRepo,
+		interval:  interval,
+		clock:     time.Now,
+	}
+}
+
+// Run rolls up recent activity immediately and then every interval until
+// ctx is done. Failures are logged and retried on the next tick.
+func (a *StatsAggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		if users, err := a.RollupRecent(ctx); err != nil {
+			log.Printf("Stats aggregation failed after %d users: %v", users, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RollupRecent rolls up yesterday and today for every user with activity
+// since the previous run, and returns the number of users rolled up. The
+// first run looks back over recentRollupDays.
+func (a *StatsAggregator) RollupRecent(ctx context.Context) (int, error) {
+	started := a.clock()
+
+	// Overlap with the previous run so that reviews committed while it was
+	// listing users are not missed
+	since := a.lastRun.Add(-a.interval)
+	if a.lastRun.IsZero() {
+		since = addDays(started, -recentRollupDays)
+	}
+
+	userIDs, err := a.statsRepo.ListActiveUsers(ctx, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list active users: %w", err)
+	}
+
+	for i, userID := range userIDs {
+		if _, err := a.statsRepo.RollupDailyStats(ctx, userID, recentRollupDays); err != nil {
+			return i, fmt.Errorf("failed to roll up user %s: %w", userID, err)
+		}
+	}
+
+	a.lastRun = started
+	return len(userIDs), nil
+}
+
+// BackfillReport is the number of days rolled up for one user
+type BackfillReport struct {
+	UserID string `json:"user_id"`
+	Days   int    `json:"days"`
+}
+
+// Backfill rolls up the whole history of the given users, or of every user
+// when none are given
+func (a *StatsAggregator) Backfill(ctx context.Context, userIDs ...string) ([]BackfillReport, error) {
+	if len(userIDs) == 0 {
+		var err error
+		userIDs, err = a.statsRepo.ListStatsUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+	}
+
+	reports := make([]BackfillReport, 0, len(userIDs))
+	for _, userID := range userIDs {
+		days, err := a.statsRepo.RollupDailyStats(ctx, userID, 0)
+		if err != nil {
+			return reports, fmt.Errorf("failed to roll up user %s: %w", userID, err)
+		}
+		reports = append(reports, BackfillReport{UserID: userID, Days: days})
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"time"
+)
+
+// Card states, as stored in user_words.card_state
+const (
+	CardStateNew        = "new"
+	CardStateLearning   = "learning"
+	CardStateReview     = "review"
+	CardStateRelearning = "relearning"
+)
+
+// Default step lists in minutes, matching the profiles column defaults
+var (
+	DefaultLearningSteps   = []int{1, 10, 60}
+	DefaultRelearningSteps = []int{10}
+)
+
+// maxStepMinutes keeps learning steps within a single day
+const maxStepMinutes = 24 * 60
+
+// StepScheduler runs new and lapsed words through short intra-day steps
+// (e.g. 1m, 10m, 1h) before handing them to the wrapped Scheduler for
+// day-based intervals.
+//
+// New words move through LearningSteps; a word failed in review is first
+// scheduled by the wrapped Scheduler (which records the lapse) and then
+// moves through RelearningSteps before it is shown again on its new interval.
+type StepScheduler struct {
+	Scheduler
+	LearningSteps   []time.Duration
+	RelearningSteps []time.Duration
+}
+
+// NewStepScheduler wraps scheduler with the given step lists in minutes
+func NewStepScheduler(scheduler Scheduler, learningSteps, relearningSteps []int) *StepScheduler {
+	return &StepScheduler{
+		Scheduler:       scheduler,
+		LearningSteps:   minutesToDurations(learningSteps),
+		RelearningSteps: minutesToDurations(relearningSteps),
+	}
+}
+
+// Schedule advances the card through its steps, graduating it to the
+// wrapped scheduler once the last step is passed (or on an easy answer)
+func (s *StepScheduler) Schedule(state CardState, quality int, now time.Time) ScheduleResult {
+	quality = clampQuality(quality)
+
+	switch normalizeCardState(state) {
+	case CardStateReview:
+		result := s.Scheduler.Schedule(state, quality, now)
+		if quality < 3 && len(s.RelearningSteps) > 0 {
+			result.State = CardStateRelearning
+			result.Step = 0
+			result.NextReviewAt = now.Add(s.RelearningSteps[0])
+		}
+		return result
+	case CardStateRelearning:
+		return s.advance(state, quality, now, CardStateRelearning, s.RelearningSteps)
+	default:
+		return s.advance(state, quality, now, CardStateLearning, s.LearningSteps)
+	}
+}
+
+// advance moves a (re)learning card to its next step:
+// fail restarts the steps, hard repeats the current step,
+// good moves to the next step and easy graduates immediately
+func (s *StepScheduler) advance(state CardState, quality int, now time.Time, phase string, steps []time.Duration) ScheduleResult {
+	step := state.Step
+	switch {
+	case len(steps) == 0 || quality == 5:
+		return s.graduate(state, quality, now, phase)
+	case quality < 3:
+		step = 0
+	case quality == 3:
+		if step >= len(steps) {
+			step = len(steps) - 1
+		}
+	default:
+		step++
+		if step >= len(steps) {
+			return s.graduate(state, quality, now, phase)
+		}
+	}
+
+	return ScheduleResult{
+		EasinessFactor: state.EasinessFactor,
+		Interval:       state.Interval,
+		Repetitions:    state.Repetitions,
+		Stability:      state.Stability,
+		Difficulty:     state.Difficulty,
+		State:          phase,
+		Step:           step,
+		NextReviewAt:   now.Add(steps[step]),
+	}
+}
+
+// graduate moves a card out of its steps into day-based review
+func (s *StepScheduler) graduate(state CardState, quality int, now time.Time, phase string) ScheduleResult {
+	if phase == CardStateRelearning {
+		// The lapse was already scheduled when the card entered relearning
+		interval := state.Interval
+		if interval < 1 {
+			interval = 1
+		}
+		return ScheduleResult{
+			EasinessFactor: state.EasinessFactor,
+			Interval:       interval,
+			Repetitions:    state.Repetitions,
+			Stability:      state.Stability,
+			Difficulty:     state.Difficulty,
+			State:          CardStateReview,
+			NextReviewAt:   addDays(now, interval),
+		}
+	}
+
+	// A graduating new word is scheduled as if this were its first review
+	first := state
+	first.LastReviewedAt = nil
+	first.Stability = 0
+	first.Difficulty = 0
+	return s.Scheduler.Schedule(first, quality, now)
+}
+
+// normalizeCardState infers the state of words scheduled before card states existed
+func normalizeCardState(state CardState) string {
+	if state.State != "" {
+		return state.State
+	}
+	if state.LastReviewedAt == nil {
+		return CardStateNew
+	}
+	return CardStateReview
+}
+
+// ValidateSteps checks a learning or relearning step list in minutes
+func ValidateSteps(steps []int) error {
+	if len(steps) > 10 {
+		return fmt.Errorf("at most 10 steps are allowed")
+	}
+	for _, step := range steps {
+		if step < 1 || step > maxStepMinutes {
+			return fmt.Errorf("steps must be between 1 and %d minutes", maxStepMinutes)
+		}
+	}
+	return nil
+}
+
+func minutesToDurations(minutes []int) []time.Duration {
+	durations := make([]time.Duration, 0, len(minutes))
+	for _, m := range minutes {
+		durations = append(durations, time.Duration(m)*time.Minute)
+	}
+	return durations
+}
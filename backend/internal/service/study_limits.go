@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Bounds of the daily study limits, in cards
+const (
+	MaxNewCardsPerDay = 999
+	MaxReviewsPerDay  = 9999
+)
+
+// ValidateStudyLimits checks daily limits and the new card ratio and order
+func ValidateStudyLimits(limits repository.StudyLimits) error {
+	if limits.NewCardsPerDay < 0 || limits.NewCardsPerDay > MaxNewCardsPerDay {
+		return fmt.Errorf("new cards per day must be between 0 and %d", MaxNewCardsPerDay)
+	}
+	if limits.ReviewsPerDay < 0 || limits.ReviewsPerDay > MaxReviewsPerDay {
+		return fmt.Errorf("reviews per day must be between 0 and %d", MaxReviewsPerDay)
+	}
+	if limits.NewCardRatio < 0 || limits.NewCardRatio > 1 {
+		return fmt.Errorf("new card ratio must be between 0 and 1")
+	}
+	switch limits.NewCardOrder {
+	case repository.NewCardOrderMixed, repository.NewCardOrderNewFirst, repository.NewCardOrderReviewsFirst:
+	default:
+		return fmt.Errorf("new card order must be %q, %q or %q",
+			repository.NewCardOrderMixed, repository.NewCardOrderNewFirst, repository.NewCardOrderReviewsFirst)
+	}
+	return nil
+}
+
+// studyCards returns up to size cards to study now, ordered by the user's
+// new card order. Reviews and new cards are each capped by what is left of
+// the user's daily limits in their current study day, and when both would
+// fill size the user's new card ratio decides the split. Each word
+// contributes at most one card so that its siblings are left for later.
+// Only cards of words matching filter are studied. It also returns how many
+// of the cards are new.
+func (s *ReviewService) studyCards(ctx context.Context, userID string, size int, filter repository.WordFilter) ([]repository.DueWord, int, error) {
+	limits, err := s.reviewRepo.GetStudyLimits(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	counts, err := s.reviewRepo.GetTodayStudyCounts(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get today's study counts: %w", err)
+	}
+
+	reviewQuota := minInt(size, limits.ReviewsPerDay-counts.Reviewed)
+	newQuota := minInt(size, limits.NewCardsPerDay-counts.Introduced)
+
+	// Cards are fetched with room for the siblings dropped below
+	seen := make(map[uuid.UUID]bool)
+	var dueWords, newWords []repository.DueWord
+	if reviewQuota > 0 {
+		dueWords, err = s.reviewRepo.GetDueWords(ctx, userID, reviewQuota*len(CardTypes), filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get due words: %w", err)
+		}
+		dueWords = withoutSiblings(dueWords, seen)
+		if len(dueWords) > reviewQuota {
+			dueWords = dueWords[:reviewQuota]
+		}
+	}
+	if newQuota > 0 {
+		newWords, err = s.reviewRepo.GetNewWords(ctx, userID, newQuota*len(CardTypes), filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get new words: %w", err)
+		}
+		newWords = withoutSiblings(newWords, seen)
+		if len(newWords) > newQuota {
+			newWords = newWords[:newQuota]
+		}
+	}
+
+	// Trim to size, keeping the user's share of it for new cards
+	if len(dueWords)+len(newWords) > size {
+		newCount := minInt(len(newWords), int(math.Ceil(float64(size)*limits.NewCardRatio)))
+		if len(dueWords) < size-newCount {
+			newCount = size - len(dueWords)
+		}
+		newWords = newWords[:newCount]
+		dueWords = dueWords[:size-newCount]
+	}
+
+	return orderCards(dueWords, newWords, limits.NewCardOrder), len(newWords), nil
+}
+
+// orderCards places new cards before, after or evenly between the due cards
+func orderCards(dueWords, newWords []repository.DueWord, order string) []repository.DueWord {
+	total := len(dueWords) + len(newWords)
+	cards := make([]repository.DueWord, 0, total)
+
+	switch order {
+	case repository.NewCardOrderNewFirst:
+		return append(append(cards, newWords...), dueWords...)
+	case repository.NewCardOrderReviewsFirst:
+		return append(append(cards, dueWords...), newWords...)
+	}
+
+	var dueIndex, newIndex int
+	for i := 0; i < total; i++ {
+		// Place a new card whenever the new cards fall behind their even share
+		if newIndex < len(newWords) && (dueIndex >= len(dueWords) || (i+1)*len(newWords) > (newIndex+1)*total-total/2) {
+			cards = append(cards, newWords[newIndex])
+			newIndex++
+		} else {
+			cards = append(cards, dueWords[dueIndex])
+			dueIndex++
+		}
+	}
+
+	return cards
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// MaxTagNameLength bounds tag names, in characters, matching the column
+const MaxTagNameLength = 50
+
+// DefaultTagColor is the colour of tags created without one, matching the
+// column default
+const DefaultTagColor = "#10B981"
+
+// Tag autocompletion limits, in tags
+const (
+	DefaultTagSuggestions = 10
+	MaxTagSuggestions     = 50
+)
+
+// MaxBulkTags bounds how many tags one bulk request attaches or detaches
+const MaxBulkTags = 50
+
+// Tag errors
+var (
+	ErrTagNotFound     = errors.New("tag not found")
+	ErrTagNameTaken    = repository.ErrTagNameTaken
+	ErrInvalidTagQuery = repository.ErrInvalidTagQuery
+)
+
+// TagService manages users' tags and the tags on their words
+type TagService struct {
+	tagRepo *repository.TagRepository
+}
+
+// NewTagService creates a new tag service instance
+func NewTagService(tagRepo *repository.TagRepository) *TagService {
+	return &TagService{tagRepo: tagRepo}
+}
+
+// CreateTagRequest represents a new tag
+type CreateTagRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// Validate checks the name and colour, trimming the name and defaulting the colour
+func (req *CreateTagRequest) Validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validateTagName(req.Name); err != nil {
+		return err
+	}
+	if req.Color == "" {
+		req.Color = DefaultTagColor
+	}
+	return validateColor(req.Color)
+}
+
+// UpdateTagRequest renames or recolours a tag; omitted fields are kept
+type UpdateTagRequest repository.TagUpdate
+
+// Validate checks that something changes and the new name and colour
+func (req *UpdateTagRequest) Validate() error {
+	if req.Name == nil && req.Color == nil {
+		return fmt.Errorf("name or color is required")
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+		if err := validateTagName(name); err != nil {
+			return err
+		}
+	}
+	if req.Color != nil {
+		return validateColor(*req.Color)
+	}
+	return nil
+}
+
+// WordTagsRequest attaches tags to, or detaches them from, every word
+// matching a filter
+type WordTagsRequest struct {
+	TagIDs []uuid.UUID           `json:"tag_ids"`
+	Filter repository.WordFilter `json:"filter"`
+}
+
+// Validate checks the tags and that the filter selects some words rather than all
+func (req *WordTagsRequest) Validate() error {
+	if len(req.TagIDs) == 0 {
+		return fmt.Errorf("tag_ids is required")
+	}
+	if len(req.TagIDs) > MaxBulkTags {
+		return fmt.Errorf("at most %d tags can be changed at once", MaxBulkTags)
+	}
+	if req.Filter.IsEmpty() {
+		return ErrEmptyFilter
+	}
+	return req.Filter.Validate()
+}
+
+// WordTagsResult is the number of words whose tags were changed
+type WordTagsResult struct {
+	TagIDs []uuid.UUID `json:"tag_ids"`
+	Words  int         `json:"words"`
+}
+
+func validateTagName(name string) error {
+	if strings.TrimLeft(name, "#") == "" {
+		return fmt.Errorf("name is required")
+	}
+	if utf8.RuneCountInString(name) > MaxTagNameLength {
+		return fmt.Errorf("name must be at most %d characters", MaxTagNameLength)
+	}
+	// Tag queries separate terms by spaces and parentheses, which quoting
+	// allows, but a quote cannot be escaped
+	if strings.Contains(name, `"`) {
+		return fmt.Errorf("name must not contain double quotes")
+	}
+	return nil
+}
+
+// ListTags returns the user's tags by name with their word counts
+func (s *TagService) ListTags(ctx context.Context, userID string) ([]repository.Tag, error) {
+	return s.tagRepo.ListTags(ctx, userID)
+}
+
+// AutocompleteTags suggests up to limit of the user's tags starting with
+// prefix, most used first
+func (s *TagService) AutocompleteTags(ctx context.Context, userID, prefix string, limit int) ([]repository.Tag, error) {
+	if limit <= 0 {
+		limit = DefaultTagSuggestions
+	}
+	if limit > MaxTagSuggestions {
+		limit = MaxTagSuggestions
+	}
+	return s.tagRepo.AutocompleteTags(ctx, userID, prefix, limit)
+}
+
+// CreateTag adds a tag for the user
+func (s *TagService) CreateTag(ctx context.Context, userID string, req CreateTagRequest) (*repository.Tag, error) {
+	return s.tagRepo.CreateTag(ctx, userID, req.Name, req.Color)
+}
+
+// UpdateTag renames or recolours one of the user's tags
+func (s *TagService) UpdateTag(ctx context.Context, userID string, tagID uuid.UUID, req UpdateTagRequest) (*repository.Tag, error) {
+	tag, err := s.tagRepo.UpdateTag(ctx, userID, tagID, repository.TagUpdate(req))
+	if err != nil {
+		return nil, err
+	}
+	if tag == nil {
+		return nil, ErrTagNotFound
+	}
+	return tag, nil
+}
+
+// DeleteTag removes one of the user's tags from every word and deletes it
+func (s *TagService) DeleteTag(ctx context.Context, userID string, tagID uuid.UUID) error {
+	deleted, err := s.tagRepo.DeleteTag(ctx, userID, tagID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// AttachTags tags every word matching the request's filter with its tags
+func (s *TagService) AttachTags(ctx context.Context, userID string, req WordTagsRequest) (*WordTagsResult, error) {
+	if err := s.checkTags(ctx, userID, req.TagIDs); err != nil {
+		return nil, err
+	}
+
+	count, err := s.tagRepo.AttachTags(ctx, userID, req.TagIDs, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WordTagsResult{TagIDs: req.TagIDs, Words: count}, nil
+}
+
+// DetachTags removes the request's tags from every word matching its filter
+func (s *TagService) DetachTags(ctx context.Context, userID string, req WordTagsRequest) (*WordTagsResult, error) {
+	if err := s.checkTags(ctx, userID, req.TagIDs); err != nil {
+		return nil, err
+	}
+
+	count, err := s.tagRepo.DetachTags(ctx, userID, req.TagIDs, req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WordTagsResult{TagIDs: req.TagIDs, Words: count}, nil
+}
+
+// checkTags returns ErrTagNotFound unless every tag in tagIDs is the user's
+func (s *TagService) checkTags(ctx context.Context, userID string, tagIDs []uuid.UUID) error {
+	unique := make(map[uuid.UUID]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		unique[id] = true
+	}
+
+	count, err := s.tagRepo.CountTags(ctx, userID, tagIDs)
+	if err != nil {
+		return err
+	}
+	if count < len(unique) {
+		return ErrTagNotFound
+	}
+	return nil
+}
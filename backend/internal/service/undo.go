@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Undo errors, returned as is from the repository
+var (
+	ErrNothingToUndo  = repository.ErrNothingToUndo
+	ErrUndoOutOfOrder = repository.ErrUndoOutOfOrder
+	ErrCardReset      = repository.ErrCardReset
+	ErrUndoLimit      = repository.ErrUndoLimit
+)
+
+// UndoRequest represents a request to undo the latest review
+type UndoRequest struct {
+	// SessionID limits undo to the answers of one session; repeated
+	// requests step back through the session one answer at a time.
+	// Without it only one review from today can be undone.
+	SessionID *uuid.UUID `json:"session_id"`
+}
+
+// UndoResult is the reverted review with the card's restored state and,
+// for session answers, the session's progress after stepping back
+type UndoResult struct {
+	Undone  *repository.UndoneReview `json:"undone"`
+	Card    *ReviewCard              `json:"card"`
+	Session *SessionSummary          `json:"session,omitempty"`
+}
+
+// UndoLastReview reverts the user's most recent review, or the most recent
+// answer of a session
+func (s *ReviewService) UndoLastReview(ctx context.Context, userID string, req UndoRequest) (*UndoResult, error) {
+	if req.SessionID != nil {
+		session, err := s.reviewRepo.GetReviewSession(ctx, userID, *req.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			return nil, ErrSessionNotFound
+		}
+	}
+
+	undone, err := s.reviewRepo.UndoLastReview(ctx, userID, req.SessionID, s.clock())
+	if err != nil {
+		return nil, err
+	}
+	s.dashboard.Invalidate(userID)
+
+	result := &UndoResult{Undone: undone}
+
+	card, err := s.reviewRepo.GetReviewCard(ctx, userID, undone.CardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get card: %w", err)
+	}
+	if card != nil {
+		reviewCard := NewReviewCard(*card)
+		result.Card = &reviewCard
+	}
+
+	if undone.SessionID != nil {
+		session, err := s.reviewRepo.GetReviewSession(ctx, userID, *undone.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if session != nil {
+			result.Session = summarizeSession(session)
+		}
+	}
+
+	return result, nil
+}
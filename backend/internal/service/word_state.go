@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"vocabweb/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Word actions change whether and how a word is reviewed without touching
+// its review history
+const (
+	WordActionSuspend   = "suspend"   // stop reviewing until unsuspended
+	WordActionUnsuspend = "unsuspend" // review again
+	WordActionBury      = "bury"      // skip until the next study day
+	WordActionUnbury    = "unbury"    // review again today
+	WordActionReset     = "reset"     // forget all progress and start as new
+)
+
+// ErrUnknownWordAction is returned for an action that is not one of the WordAction constants
+var ErrUnknownWordAction = errors.New("unknown word action")
+
+// ErrEmptyFilter is returned when a bulk action's filter would select every word
+var ErrEmptyFilter = repository.ErrEmptyFilter
+
+// BulkWordActionRequest applies an action to every word matching a filter
+type BulkWordActionRequest struct {
+	Action string                `json:"action"`
+	Filter repository.WordFilter `json:"filter"`
+}
+
+// Validate checks the action and that the filter selects some words rather than all
+func (req *BulkWordActionRequest) Validate() error {
+	if _, ok := wordActions[req.Action]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownWordAction, req.Action)
+	}
+	if req.Filter.IsEmpty() {
+		return ErrEmptyFilter
+	}
+	return req.Filter.Validate()
+}
+
+// WordActionResult is the number of words an action was applied to
+type WordActionResult struct {
+	Action string `json:"action"`
+	Words  int    `json:"words"`
+}
+
+// wordActions maps each action to the repository operation applying it
+var wordActions = map[string]func(*repository.ReviewRepository, context.Context, string, repository.WordFilter) (int, error){
+	WordActionSuspend:   (*repository.ReviewRepository).SuspendWords,
+	WordActionUnsuspend: (*repository.ReviewRepository).UnsuspendWords,
+	WordActionBury:      (*repository.ReviewRepository).BuryWords,
+	WordActionUnbury:    (*repository.ReviewRepository).UnburyWords,
+	WordActionReset:     (*repository.ReviewRepository).ResetWords,
+}
+
+// ApplyWordAction applies an action to one of the user's words
+func (s *ReviewService) ApplyWordAction(ctx context.Context, userID string, userWordID uuid.UUID, action string) (*WordActionResult, error) {
+	result, err := s.applyWordAction(ctx, userID, action, repository.WordFilter{UserWordIDs: []uuid.UUID{userWordID}})
+	if err != nil {
+		return nil, err
+	}
+	if result.Words == 0 {
+		return nil, ErrReviewWordMissing
+	}
+
+	return result, nil
+}
+
+// ApplyBulkWordAction applies an action to every word matching the request's filter
+func (s *ReviewService) ApplyBulkWordAction(ctx context.Context, userID string, req BulkWordActionRequest) (*WordActionResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.applyWordAction(ctx, userID, req.Action, req.Filter)
+}
+
+func (s *ReviewService) applyWordAction(ctx context.Context, userID, action string, filter repository.WordFilter) (*WordActionResult, error) {
+	apply, ok := wordActions[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWordAction, action)
+	}
+
+	count, err := apply(s.reviewRepo, ctx, userID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s words: %w", action, err)
+	}
+	if count > 0 {
+		s.dashboard.Invalidate(userID)
+	}
+
+	return &WordActionResult{Action: action, Words: count}, nil
+}
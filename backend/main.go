@@ -15,6 +15,7 @@ import (
 	"github.com/apex-spaces/vocabweb/backend/internal/middleware"
 	"github.com/apex-spaces/vocabweb/backend/internal/repository"
 	"github.com/apex-spaces/vocabweb/backend/internal/router"
+	"github.com/apex-spaces/vocabweb/backend/internal/service"
 )
 
 func main() {
@@ -43,19 +44,51 @@ func main() {
 	}
 
 	// Initialize repositories
+	var userRepo *repository.UserRepository
 	var statsRepo *repository.StatsRepository
+	var reviewRepo *repository.ReviewRepository
+	var groupRepo *repository.GroupRepository
+	var tagRepo *repository.TagRepository
+	var wordRepo *repository.WordRepository
 	if db != nil {
+		userRepo = repository.NewUserRepository(db)
 		statsRepo = repository.NewStatsRepository(db)
+		reviewRepo = repository.NewReviewRepository(db)
+		groupRepo = repository.NewGroupRepository(db)
+		tagRepo = repository.NewTagRepository(db)
+		wordRepo = repository.NewWordRepository(db)
+	}
+
+	// Initialize services
+	reviewService := service.NewReviewService(reviewRepo)
+	statsService := service.NewStatsService(statsRepo, reviewRepo)
+	groupService := service.NewGroupService(groupRepo)
+	tagService := service.NewTagService(tagRepo)
+	dictionaryService := service.NewDictionaryService(wordRepo)
+	dashboardService := service.NewDashboardService(statsRepo, service.DefaultDashboardTTL)
+	reviewService.SetDashboardService(dashboardService)
+
+	// Keep daily_stats current in the background
+	aggregateCtx, stopAggregator := context.WithCancel(ctx)
+	defer stopAggregator()
+	if statsRepo != nil {
+		go service.NewStatsAggregator(statsRepo, service.DefaultAggregateInterval).Run(aggregateCtx)
 	}
 
 	// Initialize handlers
 	healthHandler := handler.NewHealthHandler()
-	authHandler := handler.NewAuthHandler()
+	authHandler := handler.NewAuthHandler(userRepo)
 	wordsHandler := handler.NewWordsHandler()
-	dashboardHandler := handler.NewDashboardHandler(statsRepo)
+	wordsHandler.SetDashboardService(dashboardService)
+	dashboardHandler := handler.NewDashboardHandler(dashboardService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	statsHandler := handler.NewStatsHandler(statsService)
+	groupHandler := handler.NewGroupHandler(groupService)
+	tagHandler := handler.NewTagHandler(tagService)
+	dictionaryHandler := handler.NewDictionaryHandler(dictionaryService)
 
 	// Setup router
-	rt := router.New(healthHandler, authHandler, wordsHandler, dashboardHandler, authMiddleware)
+	rt := router.New(healthHandler, authHandler, wordsHandler, dashboardHandler, reviewHandler, statsHandler, groupHandler, tagHandler, dictionaryHandler, authMiddleware)
 	r := rt.Setup()
 
 	// Apply CORS middleware
@@ -0,0 +1,16 @@
+-- ============================================================================
+-- Rollback Pluggable Scheduler State
+-- Migration 003 Down
+-- ============================================================================
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS difficulty,
+DROP COLUMN IF EXISTS stability,
+DROP COLUMN IF EXISTS scheduler;
+
+ALTER TABLE user_words
+DROP COLUMN IF EXISTS difficulty,
+DROP COLUMN IF EXISTS stability;
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS scheduler;
@@ -0,0 +1,33 @@
+-- ============================================================================
+-- Add Pluggable Scheduler State
+-- Migration 003
+-- ============================================================================
+
+-- Per-user scheduling algorithm selection
+ALTER TABLE profiles
+ADD COLUMN scheduler VARCHAR(20) NOT NULL DEFAULT 'sm2' CHECK (scheduler IN ('sm2', 'fsrs'));
+
+-- FSRS memory state on user_words (kept alongside the SM-2 fields)
+ALTER TABLE user_words
+ADD COLUMN stability DOUBLE PRECISION DEFAULT 0 CHECK (stability >= 0),
+ADD COLUMN difficulty DOUBLE PRECISION DEFAULT 0 CHECK (difficulty >= 0);
+
+-- Record the full scheduler output on every review
+ALTER TABLE review_logs
+ADD COLUMN scheduler VARCHAR(20) NOT NULL DEFAULT 'sm2',
+ADD COLUMN stability DOUBLE PRECISION DEFAULT 0 CHECK (stability >= 0),
+ADD COLUMN difficulty DOUBLE PRECISION DEFAULT 0 CHECK (difficulty >= 0);
+
+-- Seed memory state for words that already have SM-2 history so that
+-- switching to FSRS does not start them from scratch
+UPDATE user_words
+SET stability = interval,
+    difficulty = LEAST(10, GREATEST(1, 5 + (2.5 - easiness_factor) * 5 / 1.2))
+WHERE last_reviewed_at IS NOT NULL;
+
+COMMENT ON COLUMN profiles.scheduler IS 'Spaced repetition algorithm: sm2 or fsrs';
+COMMENT ON COLUMN user_words.stability IS 'Memory stability in days (interval at which recall probability is 90%)';
+COMMENT ON COLUMN user_words.difficulty IS 'Memory difficulty (1-10), higher = harder to remember';
+COMMENT ON COLUMN review_logs.scheduler IS 'Algorithm that produced this review outcome';
+COMMENT ON COLUMN review_logs.stability IS 'Memory stability after this review';
+COMMENT ON COLUMN review_logs.difficulty IS 'Memory difficulty after this review';
@@ -0,0 +1,17 @@
+-- ============================================================================
+-- Rollback Learning and Relearning Steps
+-- Migration 004 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_user_words_learning;
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS card_state;
+
+ALTER TABLE user_words
+DROP COLUMN IF EXISTS learning_step,
+DROP COLUMN IF EXISTS card_state;
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS relearning_steps,
+DROP COLUMN IF EXISTS learning_steps;
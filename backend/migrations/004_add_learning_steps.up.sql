@@ -0,0 +1,32 @@
+-- ============================================================================
+-- Add Learning and Relearning Steps
+-- Migration 004
+-- ============================================================================
+
+-- Per-user intra-day step lists, in minutes
+ALTER TABLE profiles
+ADD COLUMN learning_steps INTEGER[] NOT NULL DEFAULT '{1,10,60}',
+ADD COLUMN relearning_steps INTEGER[] NOT NULL DEFAULT '{10}';
+
+-- Card state machine: new -> learning -> review <-> relearning
+ALTER TABLE user_words
+ADD COLUMN card_state VARCHAR(20) NOT NULL DEFAULT 'new' CHECK (card_state IN ('new', 'learning', 'review', 'relearning')),
+ADD COLUMN learning_step INTEGER NOT NULL DEFAULT 0 CHECK (learning_step >= 0);
+
+ALTER TABLE review_logs
+ADD COLUMN card_state VARCHAR(20) NOT NULL DEFAULT 'review';
+
+-- Words that have already been reviewed have graduated
+UPDATE user_words
+SET card_state = 'review'
+WHERE last_reviewed_at IS NOT NULL;
+
+-- Learning cards are looked up by due time within the current session
+CREATE INDEX idx_user_words_learning ON user_words(user_id, next_review_at)
+WHERE card_state IN ('learning', 'relearning');
+
+COMMENT ON COLUMN profiles.learning_steps IS 'Learning step delays in minutes for new words';
+COMMENT ON COLUMN profiles.relearning_steps IS 'Relearning step delays in minutes for lapsed words';
+COMMENT ON COLUMN user_words.card_state IS 'new, learning, review or relearning';
+COMMENT ON COLUMN user_words.learning_step IS 'Index into the (re)learning step list';
+COMMENT ON COLUMN review_logs.card_state IS 'Card state after this review';
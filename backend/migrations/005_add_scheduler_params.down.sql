@@ -0,0 +1,7 @@
+-- ============================================================================
+-- Rollback Personal Scheduler Parameters
+-- Migration 005 Down
+-- ============================================================================
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS scheduler_params;
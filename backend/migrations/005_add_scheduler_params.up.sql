@@ -0,0 +1,10 @@
+-- ============================================================================
+-- Add Personal Scheduler Parameters
+-- Migration 005
+-- ============================================================================
+
+-- Fitted SM-2 parameters and fit statistics, NULL until optimised
+ALTER TABLE profiles
+ADD COLUMN scheduler_params JSONB;
+
+COMMENT ON COLUMN profiles.scheduler_params IS 'Per-user SM-2 parameters fitted from review_logs, with fit statistics';
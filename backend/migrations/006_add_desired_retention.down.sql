@@ -0,0 +1,7 @@
+-- ============================================================================
+-- Rollback Desired Retention
+-- Migration 006 Down
+-- ============================================================================
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS desired_retention;
@@ -0,0 +1,12 @@
+-- ============================================================================
+-- Add Desired Retention
+-- Migration 006
+-- ============================================================================
+
+-- Target recall probability at review time; intervals are stretched or
+-- shortened so that this share of due words is remembered
+ALTER TABLE profiles
+ADD COLUMN desired_retention DECIMAL(3,2) NOT NULL DEFAULT 0.90
+    CHECK (desired_retention >= 0.70 AND desired_retention <= 0.99);
+
+COMMENT ON COLUMN profiles.desired_retention IS 'Target recall probability (0.70-0.99) review intervals are scheduled for';
@@ -0,0 +1,7 @@
+-- ============================================================================
+-- Rollback Day Rollover Hour
+-- Migration 007 Down
+-- ============================================================================
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS day_rollover_hour;
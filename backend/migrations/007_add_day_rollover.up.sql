@@ -0,0 +1,12 @@
+-- ============================================================================
+-- Add Day Rollover Hour
+-- Migration 007
+-- ============================================================================
+
+-- Local hour at which a new study day starts, so late-night reviews count
+-- towards the day they belong to
+ALTER TABLE profiles
+ADD COLUMN day_rollover_hour SMALLINT NOT NULL DEFAULT 4 CHECK (day_rollover_hour BETWEEN 0 AND 23);
+
+COMMENT ON COLUMN profiles.timezone IS 'IANA time zone used for study day boundaries';
+COMMENT ON COLUMN profiles.day_rollover_hour IS 'Local hour (0-23) at which a new study day starts';
@@ -0,0 +1,9 @@
+-- ============================================================================
+-- Rollback Review Sessions
+-- Migration 008 Down
+-- ============================================================================
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS session_id;
+
+DROP TABLE IF EXISTS review_sessions;
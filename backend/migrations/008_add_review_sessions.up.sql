@@ -0,0 +1,37 @@
+-- ============================================================================
+-- Add Review Sessions
+-- Migration 008
+-- ============================================================================
+
+CREATE TABLE review_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed')),
+    card_ids UUID[] NOT NULL DEFAULT '{}', -- Queue of user_words to show, in order
+    position INTEGER NOT NULL DEFAULT 0 CHECK (position >= 0),
+    new_cards INTEGER NOT NULL DEFAULT 0 CHECK (new_cards >= 0),
+    answered INTEGER NOT NULL DEFAULT 0 CHECK (answered >= 0),
+    correct INTEGER NOT NULL DEFAULT 0 CHECK (correct >= 0),
+    xp_earned INTEGER NOT NULL DEFAULT 0 CHECK (xp_earned >= 0),
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_answered_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_review_sessions_user FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_review_sessions_user_id ON review_sessions(user_id, started_at DESC);
+
+-- Answers given inside a session
+ALTER TABLE review_logs
+ADD COLUMN session_id UUID,
+ADD CONSTRAINT fk_review_logs_session FOREIGN KEY (session_id) REFERENCES review_sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_review_logs_session_id ON review_logs(session_id);
+
+COMMENT ON TABLE review_sessions IS 'Review sessions: a queue of cards answered one at a time';
+COMMENT ON COLUMN review_sessions.card_ids IS 'Queue of user_word IDs; failed learning cards are appended again';
+COMMENT ON COLUMN review_sessions.position IS 'Index in card_ids of the next card to show';
+COMMENT ON COLUMN review_sessions.xp_earned IS 'Experience points earned in this session';
+COMMENT ON COLUMN review_logs.session_id IS 'Review session the answer was given in, NULL for standalone reviews';
@@ -0,0 +1,19 @@
+-- ============================================================================
+-- Rollback Review Undo Snapshots
+-- Migration 009 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_review_logs_user_word_reviewed;
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS prev_easiness_factor,
+DROP COLUMN IF EXISTS prev_interval,
+DROP COLUMN IF EXISTS prev_repetitions,
+DROP COLUMN IF EXISTS prev_stability,
+DROP COLUMN IF EXISTS prev_difficulty,
+DROP COLUMN IF EXISTS prev_card_state,
+DROP COLUMN IF EXISTS prev_learning_step,
+DROP COLUMN IF EXISTS prev_last_reviewed_at,
+DROP COLUMN IF EXISTS prev_next_review_at,
+DROP COLUMN IF EXISTS xp_awarded,
+DROP COLUMN IF EXISTS requeued;
@@ -0,0 +1,26 @@
+-- ============================================================================
+-- Add Review Undo Snapshots
+-- Migration 009
+-- ============================================================================
+
+-- Scheduling state of the word before each review, so a review can be
+-- reverted exactly. NULL for reviews logged before this migration.
+ALTER TABLE review_logs
+ADD COLUMN prev_easiness_factor DECIMAL(4,2),
+ADD COLUMN prev_interval INTEGER,
+ADD COLUMN prev_repetitions INTEGER,
+ADD COLUMN prev_stability DOUBLE PRECISION,
+ADD COLUMN prev_difficulty DOUBLE PRECISION,
+ADD COLUMN prev_card_state VARCHAR(20),
+ADD COLUMN prev_learning_step INTEGER,
+ADD COLUMN prev_last_reviewed_at TIMESTAMPTZ,
+ADD COLUMN prev_next_review_at TIMESTAMPTZ,
+ADD COLUMN xp_awarded INTEGER NOT NULL DEFAULT 0 CHECK (xp_awarded >= 0),
+ADD COLUMN requeued BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Finding a user's latest review to undo
+CREATE INDEX idx_review_logs_user_word_reviewed ON review_logs(user_word_id, reviewed_at DESC);
+
+COMMENT ON COLUMN review_logs.prev_card_state IS 'Card state before the review; NULL when no undo snapshot was taken';
+COMMENT ON COLUMN review_logs.xp_awarded IS 'XP added to the profile for this answer';
+COMMENT ON COLUMN review_logs.requeued IS 'Whether the answer queued the card again at the end of its session';
@@ -0,0 +1,9 @@
+-- ============================================================================
+-- Rollback Review Context
+-- Migration 010 Down
+-- ============================================================================
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS response_time_ms,
+DROP COLUMN IF EXISTS card_mode,
+DROP COLUMN IF EXISTS client_type;
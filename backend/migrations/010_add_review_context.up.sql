@@ -0,0 +1,15 @@
+-- ============================================================================
+-- Add Review Context
+-- Migration 010
+-- ============================================================================
+
+-- How each answer was given: time to answer, card mode and client
+ALTER TABLE review_logs
+ADD COLUMN response_time_ms INTEGER CHECK (response_time_ms >= 0),
+ADD COLUMN card_mode VARCHAR(20) NOT NULL DEFAULT 'recognition'
+    CHECK (card_mode IN ('recognition', 'recall', 'spelling', 'listening')),
+ADD COLUMN client_type VARCHAR(20);
+
+COMMENT ON COLUMN review_logs.response_time_ms IS 'Milliseconds from showing the card to answering, NULL if not reported';
+COMMENT ON COLUMN review_logs.card_mode IS 'recognition (word to meaning), recall (meaning to word), spelling or listening';
+COMMENT ON COLUMN review_logs.client_type IS 'Client the answer came from: web, ios, android, extension';
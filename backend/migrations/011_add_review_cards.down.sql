@@ -0,0 +1,35 @@
+-- ============================================================================
+-- Rollback Review Cards
+-- Migration 011 Down
+-- ============================================================================
+
+-- Sessions go back to queues of user words
+UPDATE review_sessions rs
+SET card_ids = ARRAY(
+    SELECT rc.user_word_id
+    FROM unnest(rs.card_ids) WITH ORDINALITY AS q(card_id, ord)
+    JOIN review_cards rc ON rc.id = q.card_id
+    ORDER BY q.ord
+);
+
+-- Reviews of every card are kept as reviews of their word, in the mode they
+-- were answered in; cloze answers recalled the word, the closest mode
+-- without cloze
+UPDATE review_logs
+SET card_mode = 'recall'
+WHERE card_mode = 'cloze';
+
+ALTER TABLE review_logs
+DROP CONSTRAINT IF EXISTS review_logs_card_mode_check,
+ADD CONSTRAINT review_logs_card_mode_check
+    CHECK (card_mode IN ('recognition', 'recall', 'spelling', 'listening'));
+
+DROP INDEX IF EXISTS idx_review_logs_card_reviewed;
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS card_id;
+
+DROP TRIGGER IF EXISTS trg_user_words_review_cards ON user_words;
+DROP FUNCTION IF EXISTS create_review_cards();
+
+DROP TABLE IF EXISTS review_cards;
@@ -0,0 +1,127 @@
+-- ============================================================================
+-- Add Review Cards
+-- Migration 011
+-- ============================================================================
+
+-- Each collected word is reviewed through several independent cards, each
+-- with its own scheduling state:
+--   recognition  word -> meaning
+--   recall       meaning -> word
+--   spelling     meaning (and audio) -> typed word
+--   cloze        context sentence with the word blanked -> word
+CREATE TABLE review_cards (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_word_id UUID NOT NULL,
+    user_id TEXT NOT NULL,
+    card_type VARCHAR(20) NOT NULL CHECK (card_type IN ('recognition', 'recall', 'spelling', 'cloze')),
+    easiness_factor DECIMAL(4,2) NOT NULL DEFAULT 2.5 CHECK (easiness_factor >= 1.3),
+    interval INTEGER NOT NULL DEFAULT 0 CHECK (interval >= 0),
+    repetitions INTEGER NOT NULL DEFAULT 0 CHECK (repetitions >= 0),
+    stability DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (stability >= 0),
+    difficulty DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (difficulty >= 0),
+    card_state VARCHAR(20) NOT NULL DEFAULT 'new' CHECK (card_state IN ('new', 'learning', 'review', 'relearning')),
+    learning_step INTEGER NOT NULL DEFAULT 0 CHECK (learning_step >= 0),
+    last_reviewed_at TIMESTAMPTZ,
+    next_review_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_review_cards_user_word FOREIGN KEY (user_word_id) REFERENCES user_words(id) ON DELETE CASCADE,
+    CONSTRAINT fk_review_cards_user FOREIGN KEY (user_id) REFERENCES profiles(user_id) ON DELETE CASCADE,
+    CONSTRAINT unique_user_word_card_type UNIQUE (user_word_id, card_type)
+);
+
+CREATE INDEX idx_review_cards_user_word_id ON review_cards(user_word_id);
+CREATE INDEX idx_review_cards_due ON review_cards(user_id, next_review_at);
+CREATE INDEX idx_review_cards_new ON review_cards(user_id, card_type) WHERE card_state = 'new';
+
+-- Existing scheduling state becomes the recognition card
+INSERT INTO review_cards (
+    user_word_id, user_id, card_type, easiness_factor, interval, repetitions,
+    stability, difficulty, card_state, learning_step, last_reviewed_at, next_review_at
+)
+SELECT id, user_id, 'recognition', COALESCE(easiness_factor, 2.5), COALESCE(interval, 0), COALESCE(repetitions, 0),
+       COALESCE(stability, 0), COALESCE(difficulty, 0), card_state, learning_step, last_reviewed_at, next_review_at
+FROM user_words;
+
+-- Create the cards of a word when it is collected, and its cloze card once
+-- it has a context sentence containing the word
+CREATE OR REPLACE FUNCTION create_review_cards() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO review_cards (user_word_id, user_id, card_type)
+        VALUES (NEW.id, NEW.user_id, 'recognition'),
+               (NEW.id, NEW.user_id, 'recall'),
+               (NEW.id, NEW.user_id, 'spelling')
+        ON CONFLICT (user_word_id, card_type) DO NOTHING;
+    END IF;
+
+    IF NEW.context_sentence IS NOT NULL AND EXISTS (
+        SELECT 1 FROM words w
+        WHERE w.id = NEW.word_id
+          AND POSITION(LOWER(w.word) IN LOWER(NEW.context_sentence)) > 0
+    ) THEN
+        INSERT INTO review_cards (user_word_id, user_id, card_type)
+        VALUES (NEW.id, NEW.user_id, 'cloze')
+        ON CONFLICT (user_word_id, card_type) DO NOTHING;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_user_words_review_cards
+AFTER INSERT OR UPDATE OF context_sentence ON user_words
+FOR EACH ROW EXECUTE FUNCTION create_review_cards();
+
+-- Cards for words collected before this migration
+INSERT INTO review_cards (user_word_id, user_id, card_type)
+SELECT uw.id, uw.user_id, t.card_type
+FROM user_words uw
+CROSS JOIN (VALUES ('recall'), ('spelling')) AS t(card_type)
+ON CONFLICT (user_word_id, card_type) DO NOTHING;
+
+INSERT INTO review_cards (user_word_id, user_id, card_type)
+SELECT uw.id, uw.user_id, 'cloze'
+FROM user_words uw
+JOIN words w ON uw.word_id = w.id
+WHERE uw.context_sentence IS NOT NULL
+  AND POSITION(LOWER(w.word) IN LOWER(uw.context_sentence)) > 0
+ON CONFLICT (user_word_id, card_type) DO NOTHING;
+
+-- Reviews and sessions refer to cards; existing ones belong to the recognition card
+ALTER TABLE review_logs
+ADD COLUMN card_id UUID,
+ADD CONSTRAINT fk_review_logs_card FOREIGN KEY (card_id) REFERENCES review_cards(id) ON DELETE CASCADE;
+
+UPDATE review_logs rl
+SET card_id = rc.id
+FROM review_cards rc
+WHERE rc.user_word_id = rl.user_word_id
+  AND rc.card_type = 'recognition';
+
+ALTER TABLE review_logs
+ALTER COLUMN card_id SET NOT NULL;
+
+CREATE INDEX idx_review_logs_card_reviewed ON review_logs(card_id, reviewed_at DESC);
+
+UPDATE review_sessions rs
+SET card_ids = ARRAY(
+    SELECT rc.id
+    FROM unnest(rs.card_ids) WITH ORDINALITY AS q(user_word_id, ord)
+    JOIN review_cards rc ON rc.user_word_id = q.user_word_id AND rc.card_type = 'recognition'
+    ORDER BY q.ord
+);
+
+-- Cloze cards are answered in their own mode
+ALTER TABLE review_logs
+DROP CONSTRAINT IF EXISTS review_logs_card_mode_check,
+ADD CONSTRAINT review_logs_card_mode_check
+    CHECK (card_mode IN ('recognition', 'recall', 'spelling', 'listening', 'cloze'));
+
+COMMENT ON TABLE review_cards IS 'Independently scheduled review cards of a collected word';
+COMMENT ON COLUMN review_cards.card_type IS 'recognition, recall, spelling or cloze';
+COMMENT ON COLUMN review_logs.card_id IS 'Review card that was answered';
+COMMENT ON COLUMN review_sessions.card_ids IS 'Queue of review_card IDs; failed learning cards are appended again';
+COMMENT ON COLUMN user_words.next_review_at IS 'Earliest next review of the word''s cards';
+COMMENT ON COLUMN user_words.last_reviewed_at IS 'Latest review of any of the word''s cards';
+COMMENT ON COLUMN user_words.card_state IS 'State of the word''s recognition card';
@@ -0,0 +1,25 @@
+-- ============================================================================
+-- Rollback Leech Detection
+-- Migration 012 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_user_words_suspended;
+DROP INDEX IF EXISTS idx_user_words_leeches;
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS leech_action,
+DROP COLUMN IF EXISTS leech_threshold;
+
+ALTER TABLE user_words
+DROP COLUMN IF EXISTS custom_definition,
+DROP COLUMN IF EXISTS mnemonic,
+DROP COLUMN IF EXISTS suspended_at,
+DROP COLUMN IF EXISTS is_leech,
+DROP COLUMN IF EXISTS lapses;
+
+ALTER TABLE review_cards
+DROP COLUMN IF EXISTS lapses;
+
+ALTER TABLE review_logs
+DROP COLUMN IF EXISTS leech_suspended,
+DROP COLUMN IF EXISTS is_lapse;
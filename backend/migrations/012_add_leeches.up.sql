@@ -0,0 +1,74 @@
+-- ============================================================================
+-- Add Leech Detection
+-- Migration 012
+-- ============================================================================
+
+-- A lapse is a failed review (quality < 3) of a card that had graduated to
+-- review, i.e. a word that was known and then forgotten
+ALTER TABLE review_logs
+ADD COLUMN is_lapse BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN leech_suspended BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE review_cards
+ADD COLUMN lapses INTEGER NOT NULL DEFAULT 0 CHECK (lapses >= 0);
+
+ALTER TABLE user_words
+ADD COLUMN lapses INTEGER NOT NULL DEFAULT 0 CHECK (lapses >= 0),
+ADD COLUMN is_leech BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN suspended_at TIMESTAMPTZ,
+ADD COLUMN mnemonic TEXT,
+ADD COLUMN custom_definition TEXT;
+
+-- Words failed this many times are flagged as leeches, and suspended when
+-- leech_action is 'suspend'
+ALTER TABLE profiles
+ADD COLUMN leech_threshold SMALLINT NOT NULL DEFAULT 8 CHECK (leech_threshold BETWEEN 2 AND 50),
+ADD COLUMN leech_action VARCHAR(10) NOT NULL DEFAULT 'tag' CHECK (leech_action IN ('tag', 'suspend'));
+
+-- Derive lapses from existing history: review_logs.card_state is the state
+-- after each review, so the previous log's state is the state before it
+UPDATE review_logs rl
+SET is_lapse = TRUE
+FROM (
+    SELECT id, quality,
+           LAG(card_state) OVER (PARTITION BY card_id ORDER BY reviewed_at, created_at) AS state_before
+    FROM review_logs
+) h
+WHERE h.id = rl.id
+  AND h.quality < 3
+  AND h.state_before = 'review';
+
+UPDATE review_cards rc
+SET lapses = l.lapses
+FROM (
+    SELECT card_id, COUNT(*) AS lapses
+    FROM review_logs
+    WHERE is_lapse
+    GROUP BY card_id
+) l
+WHERE l.card_id = rc.id;
+
+UPDATE user_words uw
+SET lapses = c.lapses,
+    is_leech = c.max_lapses >= p.leech_threshold
+FROM (
+    SELECT user_word_id, SUM(lapses) AS lapses, MAX(lapses) AS max_lapses
+    FROM review_cards
+    GROUP BY user_word_id
+) c, profiles p
+WHERE c.user_word_id = uw.id
+  AND p.user_id = uw.user_id;
+
+CREATE INDEX idx_user_words_leeches ON user_words(user_id) WHERE is_leech;
+CREATE INDEX idx_user_words_suspended ON user_words(user_id) WHERE suspended_at IS NOT NULL;
+
+COMMENT ON COLUMN review_logs.is_lapse IS 'Review failed a card that was in review';
+COMMENT ON COLUMN review_logs.leech_suspended IS 'Review made the word a leech and suspended it';
+COMMENT ON COLUMN review_cards.lapses IS 'Number of times the card was forgotten after graduating';
+COMMENT ON COLUMN user_words.lapses IS 'Total lapses of the word''s cards';
+COMMENT ON COLUMN user_words.is_leech IS 'A card of the word reached the leech threshold';
+COMMENT ON COLUMN user_words.suspended_at IS 'When the word was suspended; suspended words are not reviewed';
+COMMENT ON COLUMN user_words.mnemonic IS 'User-written memory aid shown with the answer';
+COMMENT ON COLUMN user_words.custom_definition IS 'User-written definition shown instead of the dictionary one';
+COMMENT ON COLUMN profiles.leech_threshold IS 'Lapses of one card after which its word is a leech';
+COMMENT ON COLUMN profiles.leech_action IS 'tag or suspend: what happens when a word becomes a leech';
@@ -0,0 +1,9 @@
+-- ============================================================================
+-- Rollback Buried Words
+-- Migration 013 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_user_words_buried;
+
+ALTER TABLE user_words
+DROP COLUMN IF EXISTS buried_until;
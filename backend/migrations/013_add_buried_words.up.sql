@@ -0,0 +1,12 @@
+-- ============================================================================
+-- Add Buried Words
+-- Migration 013
+-- ============================================================================
+
+-- Buried words are skipped until the user's next study day starts
+ALTER TABLE user_words
+ADD COLUMN buried_until TIMESTAMPTZ;
+
+CREATE INDEX idx_user_words_buried ON user_words(user_id, buried_until) WHERE buried_until IS NOT NULL;
+
+COMMENT ON COLUMN user_words.buried_until IS 'Start of the study day the word is reviewed again after being buried';
@@ -0,0 +1,10 @@
+-- ============================================================================
+-- Rollback Daily Limits
+-- Migration 014 Down
+-- ============================================================================
+
+ALTER TABLE profiles
+DROP COLUMN IF EXISTS new_card_order,
+DROP COLUMN IF EXISTS new_card_ratio,
+DROP COLUMN IF EXISTS reviews_per_day,
+DROP COLUMN IF EXISTS new_cards_per_day;
@@ -0,0 +1,24 @@
+-- ============================================================================
+-- Add Daily Limits
+-- Migration 014
+-- ============================================================================
+
+-- Per-user limits on cards studied per study day, and how new cards are
+-- mixed into the due reviews
+ALTER TABLE profiles
+ADD COLUMN new_cards_per_day SMALLINT NOT NULL DEFAULT 20 CHECK (new_cards_per_day BETWEEN 0 AND 999),
+ADD COLUMN reviews_per_day INTEGER NOT NULL DEFAULT 200 CHECK (reviews_per_day BETWEEN 0 AND 9999),
+ADD COLUMN new_card_ratio NUMERIC(3,2) NOT NULL DEFAULT 0.25 CHECK (new_card_ratio BETWEEN 0 AND 1),
+ADD COLUMN new_card_order VARCHAR(15) NOT NULL DEFAULT 'mixed' CHECK (new_card_order IN ('mixed', 'new_first', 'reviews_first'));
+
+-- The review goal was never enforced; users who set one above the default
+-- limit keep being able to reach it
+UPDATE profiles
+SET reviews_per_day = daily_review_goal
+WHERE daily_review_goal > reviews_per_day
+  AND daily_review_goal <= 9999;
+
+COMMENT ON COLUMN profiles.new_cards_per_day IS 'Cards studied for the first time per study day';
+COMMENT ON COLUMN profiles.reviews_per_day IS 'Previously studied cards reviewed per study day';
+COMMENT ON COLUMN profiles.new_card_ratio IS 'Share of a due list or session kept for new cards when reviews would fill it';
+COMMENT ON COLUMN profiles.new_card_order IS 'mixed, new_first or reviews_first: where new cards go among the reviews';
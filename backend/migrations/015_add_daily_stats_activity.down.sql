@@ -0,0 +1,12 @@
+-- ============================================================================
+-- Rollback Daily Stats Activity
+-- Migration 015 Down
+-- ============================================================================
+
+ALTER TABLE daily_stats
+DROP COLUMN IF EXISTS review_goal,
+DROP COLUMN IF EXISTS time_spent_seconds;
+
+COMMENT ON COLUMN daily_stats.stat_date IS NULL;
+COMMENT ON COLUMN daily_stats.reviewed IS 'Number of words reviewed today';
+COMMENT ON COLUMN daily_stats.mastered IS 'Number of words mastered today';
@@ -0,0 +1,16 @@
+-- ============================================================================
+-- Add Daily Stats Activity
+-- Migration 015
+-- ============================================================================
+
+-- daily_stats rows are rolled up per study day in the user's time zone;
+-- time spent and the goal in force make each row a full calendar entry
+ALTER TABLE daily_stats
+ADD COLUMN time_spent_seconds INTEGER NOT NULL DEFAULT 0 CHECK (time_spent_seconds >= 0),
+ADD COLUMN review_goal INTEGER CHECK (review_goal > 0);
+
+COMMENT ON COLUMN daily_stats.stat_date IS 'Study date in the user''s time zone, starting at their rollover hour';
+COMMENT ON COLUMN daily_stats.reviewed IS 'Number of reviews answered that day';
+COMMENT ON COLUMN daily_stats.mastered IS 'Number of cards whose interval reached the mature threshold that day';
+COMMENT ON COLUMN daily_stats.time_spent_seconds IS 'Sum of reported response times of the day''s reviews';
+COMMENT ON COLUMN daily_stats.review_goal IS 'daily_review_goal of the profile when the day was rolled up';
@@ -0,0 +1,7 @@
+-- ============================================================================
+-- Rollback Activity Indexes
+-- Migration 016 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_user_words_updated_at;
+DROP INDEX IF EXISTS idx_review_cards_updated_at;
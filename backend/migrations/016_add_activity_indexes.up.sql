@@ -0,0 +1,8 @@
+-- ============================================================================
+-- Add Activity Indexes
+-- Migration 016
+-- ============================================================================
+
+-- The stats aggregator looks for users with recent activity every minute
+CREATE INDEX idx_review_cards_updated_at ON review_cards(updated_at);
+CREATE INDEX idx_user_words_updated_at ON user_words(updated_at);
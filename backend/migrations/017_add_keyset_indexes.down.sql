@@ -0,0 +1,8 @@
+-- ============================================================================
+-- Rollback Keyset Pagination Indexes
+-- Migration 017 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_user_words_user_created;
+DROP INDEX IF EXISTS idx_review_logs_reviewed_id;
+DROP INDEX IF EXISTS idx_review_logs_user_word_reviewed_id;
@@ -0,0 +1,12 @@
+-- ============================================================================
+-- Add Keyset Pagination Indexes
+-- Migration 017
+-- ============================================================================
+
+-- The word list pages by (created_at, id) by default, from the newest word
+CREATE INDEX idx_user_words_user_created ON user_words(user_id, created_at, id);
+
+-- Review history pages by (reviewed_at, id), newest first, over all of a
+-- user's words or within one word
+CREATE INDEX idx_review_logs_reviewed_id ON review_logs(reviewed_at, id);
+CREATE INDEX idx_review_logs_user_word_reviewed_id ON review_logs(user_word_id, reviewed_at, id);
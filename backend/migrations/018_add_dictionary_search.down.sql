@@ -0,0 +1,8 @@
+-- ============================================================================
+-- Rollback Dictionary Search
+-- Migration 018 Down
+-- ============================================================================
+
+DROP INDEX IF EXISTS idx_words_search_vector;
+ALTER TABLE words DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS idx_words_word_trgm;
@@ -0,0 +1,21 @@
+-- ============================================================================
+-- Add Dictionary Search
+-- Migration 018
+-- ============================================================================
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram index for typo-tolerant matching and prefix autocompletion of words
+CREATE INDEX idx_words_word_trgm ON words USING GIN (lower(word) gin_trgm_ops);
+
+-- Full-text search over the word, its meanings and its examples, weighted in that order
+ALTER TABLE words
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', word), 'A') ||
+    setweight(jsonb_to_tsvector('english', jsonb_path_query_array(definitions, '$[*].meaning'), '["string"]'), 'B') ||
+    setweight(jsonb_to_tsvector('english', jsonb_path_query_array(definitions, '$[*].example'), '["string"]'), 'C')
+) STORED;
+
+CREATE INDEX idx_words_search_vector ON words USING GIN (search_vector);
+
+COMMENT ON COLUMN words.search_vector IS 'Weighted full-text vector of the word (A), meanings (B) and examples (C)';
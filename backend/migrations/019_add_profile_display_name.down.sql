@@ -0,0 +1,6 @@
+-- ============================================================================
+-- Rollback Profile Display Name
+-- Migration 019 Down
+-- ============================================================================
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS display_name;
@@ -0,0 +1,10 @@
+-- ============================================================================
+-- Add Profile Display Name
+-- Migration 019
+-- ============================================================================
+
+-- The profile handlers read and write display_name, which 001 never created
+ALTER TABLE profiles
+ADD COLUMN IF NOT EXISTS display_name TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN profiles.display_name IS 'Name shown in the app; empty until the user sets one';
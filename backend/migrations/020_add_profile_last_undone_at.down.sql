@@ -0,0 +1,6 @@
+-- ============================================================================
+-- Rollback Last Undo Time
+-- Migration 020 Down
+-- ============================================================================
+
+ALTER TABLE profiles DROP COLUMN IF EXISTS last_undone_at;
@@ -0,0 +1,11 @@
+-- ============================================================================
+-- Add Last Undo Time
+-- Migration 020
+-- ============================================================================
+
+-- Outside a session only one review can be undone: a review recorded before
+-- the latest undo cannot be undone without its session
+ALTER TABLE profiles
+ADD COLUMN last_undone_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN profiles.last_undone_at IS 'When the user last undid a review';
@@ -27,6 +27,9 @@ export default function WordsPage() {
   const [showAnalyzeModal, setShowAnalyzeModal] = useState(false)
   const [searchQuery, setSearchQuery] = useState('')
   const [page, setPage] = useState(1)
+  // cursors[i] is the cursor of page i + 1; the first page has none
+  const [cursors, setCursors] = useState<string[]>([''])
+  const [nextCursor, setNextCursor] = useState('')
   const [limit] = useState(20)
 
   // Add word form state
@@ -51,8 +54,19 @@ export default function WordsPage() {
     setLoading(true)
     try {
       const token = localStorage.getItem('token')
+      const params = new URLSearchParams({
+        limit: String(limit),
+        sort: 'created_at',
+        order: 'desc'
+      })
+      if (cursors[page - 1]) {
+        params.set('cursor', cursors[page - 1])
+      }
+      if (searchQuery.trim()) {
+        params.set('q', searchQuery.trim())
+      }
       const response = await fetch(
-        `http://localhost:8080/api/v1/words?page=${page}&limit=${limit}&sort=created_at&order=desc`,
+        `http://localhost:8080/api/v1/words?${params}`,
         {
           headers: {
             'Authorization': `Bearer ${token}`
@@ -61,6 +75,7 @@ export default function WordsPage() {
       )
       const data = await response.json()
       setWords(data.words || [])
+      setNextCursor(data.next_cursor || '')
     } catch (error) {
       console.error('Failed to fetch words:', error)
     } finally {
@@ -157,7 +172,11 @@ export default function WordsPage() {
               type="text"
               placeholder="Search words..."
               value={searchQuery}
-              onChange={(e) => setSearchQuery(e.target.value)}
+              onChange={(e) => {
+                setSearchQuery(e.target.value)
+                setPage(1)
+                setCursors([''])
+              }}
               className="flex-1 px-4 py-2 bg-gray-800 border border-gray-700 rounded-lg text-white placeholder-gray-500 focus:outline-none focus:border-blue-500"
             />
             <button
@@ -235,8 +254,11 @@ export default function WordsPage() {
             </button>
             <span className="px-4 py-2 text-gray-400">Page {page}</span>
             <button
-              onClick={() => setPage(p => p + 1)}
-              disabled={words.length < limit}
+              onClick={() => {
+                setCursors(c => [...c.slice(0, page), nextCursor])
+                setPage(p => p + 1)
+              }}
+              disabled={!nextCursor}
               className="px-4 py-2 bg-gray-800 text-white rounded-lg disabled:opacity-50 disabled:cursor-not-allowed hover:bg-gray-700"
             >
               Next